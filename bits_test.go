@@ -54,3 +54,21 @@ func Test_makeMask16(t *testing.T) {
 		assert.Equal(t, expected, vuint16, fmt.Sprintf("unexpected mask for length %d", length))
 	}
 }
+
+type bitsHeader struct {
+	Version uint8  `packet:"length=4b"`
+	Length  uint16 `packet:"length=12b"`
+	Flags   uint8  `packet:"length=3b"`
+	Offset  uint16 `packet:"length=13b"`
+}
+
+func TestUnmarshalBitsUnexpectedEnd(t *testing.T) {
+	h := &bitsHeader{}
+	assert.NoError(t, Unmarshal([]byte{0x40, 0x14, 0x40, 0x01}, h))
+	assert.Equal(t, &bitsHeader{Version: 4, Length: 20, Flags: 2, Offset: 1}, h)
+
+	err := Unmarshal([]byte{0x40, 0x14, 0x40}, &bitsHeader{})
+	if assert.IsType(t, &UnmarshalUnexpectedEnd{}, err) {
+		assert.Equal(t, "Offset", err.(*UnmarshalUnexpectedEnd).Field)
+	}
+}
@@ -0,0 +1,70 @@
+package packet
+
+import (
+	"reflect"
+)
+
+// Context provides the state of the decoding (or encoding) process to InstanceForContext and LengthForContext.
+// It's only valid for the duration of the call.
+type Context struct {
+	// Session is the user supplied state from UnmarshalOptions or MarshalOptions
+	Session interface{}
+
+	parents []reflect.Value
+	data    []byte
+	offset  uint64
+	end     uint64
+}
+
+// Parent returns the n-th ancestor as a pointer when possible, 0 being the struct holding the field in question,
+// 1 the struct holding that struct and so on. It returns nil when n is out of range.
+func (ctx *Context) Parent(n int) interface{} {
+	i := len(ctx.parents) - 1 - n
+	if n < 0 || i < 0 {
+		return nil
+	}
+	v := ctx.parents[i]
+	if v.CanAddr() {
+		return v.Addr().Interface()
+	}
+	return v.Interface()
+}
+
+// Depth returns the number of ancestors
+func (ctx *Context) Depth() int {
+	return len(ctx.parents)
+}
+
+// Offset returns the byte offset of the field in question, from the beginning of the data
+func (ctx *Context) Offset() uint64 {
+	return ctx.offset
+}
+
+// Remaining returns the number of bytes left for decoding, within the boundary of the enclosing length.
+// It's always 0 when encoding.
+func (ctx *Context) Remaining() uint64 {
+	return ctx.end - ctx.offset
+}
+
+// Bytes returns the bytes left for decoding, within the boundary of the enclosing length.
+// It's always empty when encoding.
+func (ctx *Context) Bytes() []byte {
+	if ctx.data == nil {
+		return nil
+	}
+	return ctx.data[ctx.offset:ctx.end]
+}
+
+func (ctx *Context) push(v reflect.Value) {
+	ctx.parents = append(ctx.parents, v)
+}
+
+func (ctx *Context) pop() {
+	ctx.parents = ctx.parents[:len(ctx.parents)-1]
+}
+
+// at update the context to the position of the cursor
+func (ctx *Context) at(offset, end uint64) *Context {
+	ctx.offset, ctx.end = offset, end
+	return ctx
+}
@@ -0,0 +1,84 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ctxSession struct {
+	wide bool
+}
+
+type ctxOuter struct {
+	Kind  uint8
+	Inner ctxInner
+}
+
+type ctxInner struct {
+	Value uint32 `packet:"lengthfor"`
+	Body  interface{}
+}
+
+type ctxBody struct {
+	Data []byte
+}
+
+type ctxSeen struct {
+	offset    uint64
+	remaining uint64
+	bytes     []byte
+	depth     int
+}
+
+var seen ctxSeen
+
+// LengthForContext returns 4 bytes for Value on a wide session, 2 bytes otherwise
+func (i ctxInner) LengthForContext(ctx *Context, fieldname string) uint64 {
+	if s, ok := ctx.Session.(*ctxSession); ok && s.wide {
+		return 4
+	}
+	return 2
+}
+
+// InstanceForContext looks up the Kind from the enclosing ctxOuter
+func (i ctxInner) InstanceForContext(ctx *Context, fieldname string) interface{} {
+	seen = ctxSeen{offset: ctx.Offset(), remaining: ctx.Remaining(), bytes: ctx.Bytes(), depth: ctx.Depth()}
+	if o, ok := ctx.Parent(1).(*ctxOuter); ok && o.Kind == 1 {
+		return &ctxBody{}
+	}
+	return nil
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	data := []byte{0x01, 0x00, 0x0a, 0xbe, 0xef}
+
+	o := &ctxOuter{}
+	assert.NoError(t, Unmarshal(data, o))
+	assert.Equal(t, &ctxOuter{Kind: 1, Inner: ctxInner{Value: 10, Body: &ctxBody{Data: []byte{0xbe, 0xef}}}}, o)
+	assert.Equal(t, ctxSeen{offset: 3, remaining: 2, bytes: []byte{0xbe, 0xef}, depth: 2}, seen)
+
+	b, err := Marshal(o)
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	session := &ctxSession{wide: true}
+	o = &ctxOuter{}
+	assert.NoError(t, UnmarshalOptions{Session: session}.Unmarshal(data, o))
+	assert.Equal(t, &ctxOuter{Kind: 1, Inner: ctxInner{Value: 0x000abeef, Body: &ctxBody{}}}, o)
+
+	b, err = MarshalOptions{Session: session}.Marshal(o)
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	data[0] = 0x02
+	o = &ctxOuter{}
+	assert.NoError(t, Unmarshal(data, o))
+	assert.Equal(t, &ctxOuter{Kind: 2, Inner: ctxInner{Value: 10, Body: &Raw{0xbe, 0xef}}}, o)
+}
+
+func TestContextParent(t *testing.T) {
+	ctx := &Context{}
+	assert.Nil(t, ctx.Parent(0))
+	assert.Nil(t, ctx.Bytes())
+}
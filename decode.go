@@ -16,6 +16,9 @@ type cursor struct {
 const _maxCursors = 16
 
 type decoder struct {
+	maxDepth int
+	order    binary.ByteOrder
+	little   bool
 	data     []byte
 	cursor   [_maxCursors]cursor
 	currentC int
@@ -23,6 +26,7 @@ type decoder struct {
 		data   uint64
 		length uint64
 	}
+	ctx Context
 }
 
 // InstanceFor interface helps the unmarshaller to figure out the right type base on message data, by returning the object reference for the attribute in question
@@ -35,15 +39,55 @@ type LengthFor interface {
 	LengthFor(fieldname string) uint64
 }
 
+// InstanceForContext interface is InstanceFor with access to the decoding Context, e.g. ancestors and session state.
+// It takes precedence over InstanceFor.
+type InstanceForContext interface {
+	InstanceForContext(ctx *Context, fieldname string) interface{}
+}
+
+// LengthForContext interface is LengthFor with access to the decoding (or encoding) Context, e.g. ancestors and session
+// state. It takes precedence over LengthFor.
+type LengthForContext interface {
+	LengthForContext(ctx *Context, fieldname string) uint64
+}
+
 // UnmarshalPACKET interface for custome unmarshaller
 type UnmarshalPACKET interface {
 	UnmarshalPACKET(b []byte) error
 }
 
+// UnmarshalOptions configures the unmarshaller
+type UnmarshalOptions struct {
+	// Session is the user supplied state, available to InstanceForContext and LengthForContext as Context.Session
+	Session interface{}
+	// MaxDepth limits the nesting of structs, e.g. tunnels carrying tunnels, DefaultMaxDepth when it's zero
+	MaxDepth int
+	// ByteOrder of the integers, either binary.BigEndian (the default when it's nil) or binary.LittleEndian,
+	// bit fields are always most significant bit first
+	ByteOrder binary.ByteOrder
+}
+
+// DefaultMaxDepth the default nesting limit of structs for Unmarshal
+const DefaultMaxDepth = 64
+
 // Unmarshal parson the packet data and stores the result in value pointed by v.
 // If v is nil or not a pointer, Unmarshal returns an InvalidUnmarshalError.
 func Unmarshal(data []byte, v interface{}) error {
-	d := &decoder{data: data, currentC: 0}
+	return UnmarshalOptions{}.Unmarshal(data, v)
+}
+
+// Unmarshal is the same as Unmarshal with the options applied
+func (o UnmarshalOptions) Unmarshal(data []byte, v interface{}) error {
+	d := &decoder{data: data, currentC: 0, maxDepth: o.MaxDepth}
+	if d.maxDepth == 0 {
+		d.maxDepth = DefaultMaxDepth
+	}
+	d.order, d.little = binary.ByteOrder(binary.BigEndian), o.ByteOrder == binary.LittleEndian
+	if d.little {
+		d.order = binary.LittleEndian
+	}
+	d.ctx.Session = o.Session
+	d.ctx.data = data
 	c := &d.cursor[d.currentC]
 	c.start = 0
 	c.current = 0
@@ -90,6 +134,14 @@ func (d *decoder) _ptr(c *cursor, v reflect.Value) error {
 
 func (d *decoder) _struct(c *cursor, v reflect.Value) error {
 	vf := getStructFields(v)
+	d.ctx.push(v)
+	defer d.ctx.pop()
+	if d.ctx.Depth() > d.maxDepth {
+		return &UnmarshalDepthError{Struct: v.Type().Name(), Offset: int64(c.current), MaxDepth: d.maxDepth}
+	}
+	if spec := tlvSpec(v); spec != nil {
+		return d._tlv(c, v, vf, spec)
+	}
 	for i := 0; i < len(*vf); i++ {
 		if err := d.setFieldValue(c, (*vf)[i], v, v.Field(i)); err != nil {
 			return err
@@ -98,8 +150,63 @@ func (d *decoder) _struct(c *cursor, v reflect.Value) error {
 	return nil
 }
 
-func (d *decoder) getBitsByLength(c *cursor, length uint64) uint64 {
+// _tlv decodes the TLV struct, stopping after the type for padding, and bounding the value by the length. The rest
+// of the data is skipped after an end TLV.
+func (d *decoder) _tlv(c *cursor, v reflect.Value, vf *valueFields, spec *TLVSpec) error {
+	start := c.current
+	for i := 0; i < len(*vf); i++ {
+		f, fv := (*vf)[i], v.Field(i)
+		if f.tlv != _tlvValue {
+			if err := d.setFieldValue(c, f, v, fv); err != nil {
+				return err
+			}
+			if f.tlv == _tlvType && spec.padding(fv.Uint()) {
+				break
+			}
+			continue
+		}
+		lv, _ := tlvField(v, vf, _tlvLength)
+		size, ok := spec.valueLength(lv.Uint(), c.current-start)
+		if !ok || c.current+size > c.end {
+			return &UnmarshalUnexpectedEnd{Struct: v.Type().Name(), Field: f.Name, Offset: int64(c.current), End: int64(c.end)}
+		}
+		newc := &cursor{start: c.current, end: c.current + size, current: c.current}
+		var err error
+		var i interface{}
+		if tv, _ := tlvField(v, vf, _tlvType); tv.IsValid() {
+			i = spec.instance(tv.Uint())
+		}
+		if i != nil && fv.Kind() == reflect.Interface {
+			iv := reflect.ValueOf(i)
+			fv.Set(iv)
+			err = d.setValue(newc, f.StructField, v, iv.Elem())
+		} else {
+			// falls back to InstanceFor and Raw
+			err = d.setValue(newc, f.StructField, v, fv)
+		}
+		c.current = newc.end
+		if err != nil {
+			return err
+		}
+		// skip the alignment, which may be left out at the end of the data
+		if pad := spec.alignPadding(size); c.current+pad <= c.end {
+			c.current += pad
+		} else {
+			c.current = c.end
+		}
+	}
+	if tv, _ := tlvField(v, vf, _tlvType); tv.IsValid() && spec.end(tv.Uint()) {
+		c.current = c.end
+	}
+	return nil
+}
+
+// getBitsByLength returns the next length bits, false when the bits run past the end of the cursor
+func (d *decoder) getBitsByLength(c *cursor, length uint64) (uint64, bool) {
 	for d.bits.length < length {
+		if c.current >= c.end {
+			return 0, false
+		}
 		d.bits.data <<= 8
 		d.bits.data |= uint64(d.data[c.current])
 		d.bits.length += 8
@@ -109,7 +216,7 @@ func (d *decoder) getBitsByLength(c *cursor, length uint64) uint64 {
 	value := mask & d.bits.data
 	value >>= (d.bits.length - length)
 	d.bits.length -= length
-	return value
+	return value, true
 }
 
 const sliceInitialCapacity = 8
@@ -149,18 +256,18 @@ func (d *decoder) setUintValue(c *cursor, f reflect.StructField, parent reflect.
 	case 1:
 		value = uint64(d.data[c.current])
 	case 2:
-		value = uint64(binary.BigEndian.Uint16(d.data[c.current : c.current+length]))
+		value = uint64(d.order.Uint16(d.data[c.current : c.current+length]))
 	case 4:
-		value = uint64(binary.BigEndian.Uint32(d.data[c.current : c.current+length]))
+		value = uint64(d.order.Uint32(d.data[c.current : c.current+length]))
 	case 8:
-		value = binary.BigEndian.Uint64(d.data[c.current : c.current+length])
+		value = d.order.Uint64(d.data[c.current : c.current+length])
 	}
 	v.SetUint(value)
 	c.current += length
 	return nil
 }
 
-func (d *decoder) nextInstance(parent reflect.Value, f reflect.StructField) interface{} {
+func (d *decoder) nextInstance(c *cursor, parent reflect.Value, f reflect.StructField) interface{} {
 	// alt implementation, increased allocation and worst performance
 	// if m := parent.MethodByName("InstanceFor"); m.IsValid() {
 	// 	v := parent.FieldByName(f.Name)
@@ -171,6 +278,9 @@ func (d *decoder) nextInstance(parent reflect.Value, f reflect.StructField) inte
 	// 	}
 	// }
 	// return reflect.Value{}
+	if m, ok := parent.Interface().(InstanceForContext); ok {
+		return m.InstanceForContext(d.ctx.at(c.current, c.end), f.Name)
+	}
 	if m, ok := parent.Interface().(InstanceFor); ok {
 		return m.InstanceFor(f.Name)
 	}
@@ -178,7 +288,8 @@ func (d *decoder) nextInstance(parent reflect.Value, f reflect.StructField) inte
 }
 
 func (d *decoder) setValue(c *cursor, f reflect.StructField, parent reflect.Value, v reflect.Value) error {
-	if c.current >= c.end && v.Kind() != reflect.Interface {
+	if c.current >= c.end && d.bits.length == 0 && v.Kind() != reflect.Interface {
+		// nothing left to decode, unless there are bits pending for bit fields
 		return nil
 	}
 	if v.CanInterface() {
@@ -235,15 +346,24 @@ func (d *decoder) setValue(c *cursor, f reflect.StructField, parent reflect.Valu
 		v.Set(pv)
 		return d.setValue(c, f, parent, pv.Elem())
 	case reflect.Interface:
-		if i := d.nextInstance(parent, f); i != nil {
+		if i := d.nextInstance(c, parent, f); i != nil {
 			// set body before the decoding process, so it should be returned along with error if any
 			iv := reflect.ValueOf(i)
 			v.Set(iv)
 			return d.setValue(c, f, parent, iv.Elem())
 		}
+		if c.current < c.end {
+			// no instance for the remaining bytes, keep them as Raw
+			raw := &Raw{}
+			v.Set(reflect.ValueOf(raw))
+			return d.setValue(c, f, parent, reflect.ValueOf(raw).Elem())
+		}
 		return nil
 	case reflect.Bool:
 		return d.setBitFieldValue(c, f, _bits, 1, parent, v)
+	case reflect.String:
+		v.SetString(string(d.data[c.current:c.end]))
+		c.current = c.end
 	default:
 		panic(fmt.Errorf("unhandled type %s.%s %+v", parent.Type().Name(), v.Type(), v))
 	}
@@ -256,7 +376,10 @@ func (d *decoder) setBitFieldValue(c *cursor, f reflect.StructField, u unit, len
 		if (length + d.bits.length) > 64 {
 			return &UnmarshalBitfieldOverflowError{Field: f}
 		}
-		value := d.getBitsByLength(c, length)
+		value, ok := d.getBitsByLength(c, length)
+		if !ok {
+			return &UnmarshalUnexpectedEnd{Struct: parent.Type().Name(), Field: f.Name, Offset: int64(c.current), End: int64(c.end)}
+		}
 		switch v.Kind() {
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			v.SetUint(value)
@@ -266,13 +389,12 @@ func (d *decoder) setBitFieldValue(c *cursor, f reflect.StructField, u unit, len
 			v.SetBool((0xfffffffffffffff1 & value) == 0x1)
 		}
 	case _byte:
+		if c.current+length > c.end {
+			return &UnmarshalUnexpectedEnd{Struct: parent.Type().Name(), Field: f.Name, Offset: int64(c.current), End: int64(c.end)}
+		}
 		switch v.Kind() {
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			value, read := binary.Uvarint(d.data[c.current : c.current+length])
-			if read != int(length) {
-				panic(fmt.Errorf("trying to read %d bytes from data, but got %d instead", length, read))
-			}
-			v.SetUint(value)
+			v.SetUint(d.uintValue(d.data[c.current : c.current+length]))
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			value, read := binary.Varint(d.data[c.current : c.current+length])
 			if read != int(length) {
@@ -299,6 +421,19 @@ func (d *decoder) setBitFieldValue(c *cursor, f reflect.StructField, u unit, len
 	return nil
 }
 
+// uintValue returns the unsigned integer of the bytes in the byte order
+func (d *decoder) uintValue(b []byte) uint64 {
+	value := uint64(0)
+	for i := range b {
+		if d.little {
+			value |= uint64(b[i]) << (8 * uint(i))
+		} else {
+			value = value<<8 | uint64(b[i])
+		}
+	}
+	return value
+}
+
 // use a cursor to limit the byte being read during recursive parsing
 var _cursorPool = sync.Pool{
 	New: func() interface{} {
@@ -314,14 +449,18 @@ func (d *decoder) setFieldValue(c *cursor, f *field, parent reflect.Value, v ref
 		// unexported fields
 		return nil
 	}
+	if f.when != nil && !f.when.met(parent) {
+		return nil
+	}
 	newc := c
 	switch {
 	case f.length != nil:
 		return d.setBitFieldValue(c, f.StructField, f.length.unit, f.length.length, parent, v)
+	case f.countfrom != "" || f.f.sizefor:
+		return d.setSliceValue(c, f, parent, v)
 	case f.f.lengthfor:
 		// call LengthFor interface to figure out the length
-		if m, ok := parent.Interface().(LengthFor); ok {
-			length := m.LengthFor(f.Name)
+		if length, ok := d.lengthFor(c, parent, f); ok {
 			// cursor put a boundry for number of bytes to decode
 			newc = _cursorPool.Get().(*cursor)
 			// newc = &cursor{start: c.current, end: c.current + length, current: c.current}
@@ -343,3 +482,51 @@ func (d *decoder) setFieldValue(c *cursor, f *field, parent reflect.Value, v ref
 		return err
 	}
 }
+
+func (d *decoder) lengthFor(c *cursor, parent reflect.Value, f *field) (uint64, bool) {
+	switch m := parent.Interface().(type) {
+	case LengthForContext:
+		return m.LengthForContext(d.ctx.at(c.current, c.end), f.Name), true
+	case LengthFor:
+		return m.LengthFor(f.Name), true
+	}
+	return 0, false
+}
+
+// setSliceValue decodes slice elements, the number of elements is taken from the countfrom field when provided,
+// and the size in bytes for each element is returned by LengthFor when sizefor is specified.
+func (d *decoder) setSliceValue(c *cursor, f *field, parent reflect.Value, v reflect.Value) error {
+	count := -1
+	if f.countfrom != "" {
+		count = int(parent.FieldByName(f.countfrom).Uint())
+		d.growSlice(v, 0, count)
+	}
+	size := uint64(0)
+	if f.f.sizefor {
+		size, _ = d.lengthFor(c, parent, f)
+	}
+	for j := 0; j != count && c.current < c.end; j++ {
+		if j >= v.Cap() {
+			d.growSlice(v, j, 0)
+		}
+		if j >= v.Len() {
+			v.SetLen(j + 1)
+		}
+		fv := v.Index(j)
+		if size == 0 {
+			if err := d.setValue(c, f.StructField, parent, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if c.current+size > c.end {
+			return &UnmarshalUnexpectedEnd{Struct: parent.Type().Name(), Field: f.Name, Offset: int64(c.current), End: int64(c.end)}
+		}
+		newc := &cursor{start: c.current, end: c.current + size, current: c.current}
+		if err := d.setValue(newc, f.StructField, parent, fv); err != nil {
+			return err
+		}
+		c.current += size
+	}
+	return nil
+}
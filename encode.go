@@ -2,6 +2,7 @@ package packet
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"reflect"
 )
@@ -11,6 +12,13 @@ type MarshalPACKET interface {
 	MarshalPACKET() ([]byte, error)
 }
 
+// InstanceType interface is the inverse of InstanceFor, it helps the marshaller to figure out the discriminator for
+// the object set on the attribute in question. It returns the name of the discriminator field along with its value,
+// or an empty name when the object is not recognized.
+type InstanceType interface {
+	InstanceType(fieldname string, instance interface{}) (string, uint64)
+}
+
 type encoder struct {
 	bytes.Buffer
 	scratch [64]byte
@@ -19,11 +27,29 @@ type encoder struct {
 		data   uint64
 		length uint64
 	}
+	ctx    Context
+	little bool
+}
+
+// MarshalOptions configures the marshaller
+type MarshalOptions struct {
+	// Session is the user supplied state, available to LengthForContext as Context.Session
+	Session interface{}
+	// ByteOrder of the integers, either binary.BigEndian (the default when it's nil) or binary.LittleEndian,
+	// bit fields are always most significant bit first
+	ByteOrder binary.ByteOrder
 }
 
 // Marshal encode object into binary bytes
 func Marshal(v interface{}) ([]byte, error) {
+	return MarshalOptions{}.Marshal(v)
+}
+
+// Marshal is the same as Marshal with the options applied
+func (o MarshalOptions) Marshal(v interface{}) ([]byte, error) {
 	e := new(encoder)
+	e.ctx.Session = o.Session
+	e.little = o.ByteOrder == binary.LittleEndian
 	rv := reflect.ValueOf(v)
 	err := e.encode(rv, nil)
 	return e.Bytes(), err
@@ -45,12 +71,17 @@ func (e *encoder) encode(v reflect.Value, f *field) error {
 		return e.encode(pv, f)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return e._primitives(v, v, f)
+	case reflect.Bool:
+		return e.encodeBitFieldValue(v, _bits, 1)
 	case reflect.String:
 		_, err := e.Write([]byte(v.String()))
 		return err
 	case reflect.Struct:
 		return e._struct(v)
 	case reflect.Slice, reflect.Array:
+		if f != nil && f.length != nil && f.length.unit == _byte && v.Type().Elem().Kind() == reflect.Uint8 {
+			return e.encodeBytes(v, f.length.length)
+		}
 		for j := 0; j < v.Len(); j++ {
 			vf := v.Index(j)
 			if vf.CanSet() {
@@ -68,13 +99,56 @@ func (e *encoder) encode(v reflect.Value, f *field) error {
 }
 
 func (e *encoder) fieldEncode(parent reflect.Value, v reflect.Value, f *field) error {
+	if f.when != nil && !f.when.met(parent) {
+		return nil
+	}
 	switch v.Kind() {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return e._primitives(parent, v, f)
+	case reflect.Slice, reflect.Array:
+		if f.f.lengthfor && v.Type().Elem().Kind() == reflect.Uint8 {
+			if length, ok := e.lengthFor(parent, f); ok {
+				return e.encodeBytes(v, length)
+			}
+		}
+		if f.f.sizefor {
+			size, _ := e.lengthFor(parent, f)
+			for j := 0; j < v.Len(); j++ {
+				if err := e.encodeBitFieldValue(v.Index(j), _byte, size); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
 	}
 	return e.encode(v, f)
 }
 
+func (e *encoder) lengthFor(parent reflect.Value, f *field) (uint64, bool) {
+	offset := uint64(e.Len())
+	switch m := parent.Interface().(type) {
+	case LengthForContext:
+		return m.LengthForContext(e.ctx.at(offset, offset), f.Name), true
+	case LengthFor:
+		return m.LengthFor(f.Name), true
+	}
+	return 0, false
+}
+
+// encodeBytes writes exactly length bytes from the byte slice or array, padding with zeros when it's short
+func (e *encoder) encodeBytes(v reflect.Value, length uint64) error {
+	for j := uint64(0); j < length; j++ {
+		b := uint8(0)
+		if j < uint64(v.Len()) {
+			b = uint8(v.Index(int(j)).Uint())
+		}
+		if err := e.WriteByte(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (e *encoder) writeBits() error {
 	for ; e.bits.length >= 8; e.bits.length -= 8 {
 		e.scratch[e.current] = uint8(e.bits.data >> (e.bits.length - 8))
@@ -96,11 +170,27 @@ func (e *encoder) encodeBitFieldValue(v reflect.Value, u unit, length uint64) er
 			e.bits.data |= (mask & value)
 			e.bits.length += length
 			return e.writeBits()
+		case reflect.Bool:
+			e.bits.data <<= length
+			if v.Bool() {
+				e.bits.data |= 0x1
+			}
+			e.bits.length += length
+			return e.writeBits()
 		}
 
 	case _byte:
 		switch v.Kind() {
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if e.little && e.bits.length == 0 {
+				value := v.Uint()
+				for j := uint64(0); j < length; j++ {
+					if err := e.WriteByte(uint8(value >> (8 * j))); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
 			rlength := length * 8
 			mask := makeMask(uint(rlength + e.bits.length))
 			value := v.Uint()
@@ -120,8 +210,8 @@ func (e *encoder) _primitives(parent reflect.Value, v reflect.Value, f *field) e
 		case f.length != nil:
 			return e.encodeBitFieldValue(v, f.length.unit, f.length.length)
 		case f.f.lengthfor:
-			if m, ok := parent.Interface().(LengthFor); ok {
-				length = m.LengthFor(f.Name)
+			var ok bool
+			if length, ok = e.lengthFor(parent, f); ok {
 				goto encode
 			}
 		}
@@ -146,6 +236,26 @@ encode:
 
 func (e *encoder) _struct(v reflect.Value) error {
 	vf := getStructFields(v)
+	e.ctx.push(v)
+	defer e.ctx.pop()
+	types, err := e.instanceTypes(v, vf)
+	if err != nil {
+		return err
+	}
+	if len(types) > 0 {
+		// encode a copy with the inferred discriminators, so LengthFor and the descendants see them
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for name, tv := range types {
+			c.FieldByName(name).Set(tv)
+		}
+		v = c
+		e.ctx.pop()
+		e.ctx.push(v)
+	}
+	if spec := tlvSpec(v); spec != nil {
+		return e._tlv(v, vf, spec)
+	}
 	for i := 0; i < len(*vf); i++ {
 		if err := e.fieldEncode(v, v.Field(i), (*vf)[i]); err != nil {
 			return err
@@ -154,3 +264,173 @@ func (e *encoder) _struct(v reflect.Value) error {
 
 	return nil
 }
+
+// _tlv encodes the TLV struct, stopping after the type for padding, and patching the length from the encoded value
+func (e *encoder) _tlv(v reflect.Value, vf *valueFields, spec *TLVSpec) error {
+	start := e.bitOffset()
+	var lengthAt, lengthWidth, valueAt uint64
+	for i := 0; i < len(*vf); i++ {
+		f, fv := (*vf)[i], v.Field(i)
+		switch f.tlv {
+		case _tlvLength:
+			lengthAt = e.bitOffset()
+		case _tlvValue:
+			valueAt = e.bitOffset()
+		}
+		if err := e.fieldEncode(v, fv, f); err != nil {
+			return err
+		}
+		switch f.tlv {
+		case _tlvType:
+			if spec.padding(fv.Uint()) {
+				return nil
+			}
+		case _tlvLength:
+			lengthWidth = e.bitOffset() - lengthAt
+		case _tlvValue:
+			value, header := (e.bitOffset()-valueAt)/8, (valueAt-start)/8
+			if lengthWidth == 0 {
+				if err := e.align(spec, value); err != nil {
+					return err
+				}
+				continue
+			}
+			length := spec.length(value, header)
+			if length>>lengthWidth != 0 {
+				return &MarshalLengthError{Struct: v.Type().Name(), Field: f.Name, Length: length}
+			}
+			e.patchBits(lengthAt, lengthWidth, length)
+			// pad the value to the unit of the length
+			size, _ := spec.valueLength(length, header)
+			for ; value < size; value++ {
+				if err := e.WriteByte(0); err != nil {
+					return err
+				}
+			}
+			if err := e.align(spec, size); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// align pads the value of the size with zeros to the alignment of the spec
+func (e *encoder) align(spec *TLVSpec, size uint64) error {
+	for pad := spec.alignPadding(size); pad > 0; pad-- {
+		if err := e.WriteByte(0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bitOffset returns the offset in bits, including the pending bits of bit fields
+func (e *encoder) bitOffset() uint64 {
+	return uint64(e.Len())*8 + e.bits.length
+}
+
+// patchBits overwrites the width bits at the offset in bits with the value, whole bytes being in the byte order
+func (e *encoder) patchBits(offset, width, value uint64) {
+	b := e.Bytes()
+	if e.little && offset%8 == 0 && width%8 == 0 {
+		for j := uint64(0); j < width/8; j++ {
+			b[offset/8+j] = uint8(value >> (8 * j))
+		}
+		return
+	}
+	for k := uint64(0); k < width; k++ {
+		bit := offset + k
+		mask := uint8(0x80) >> (bit % 8)
+		if (value>>(width-1-k))&1 == 1 {
+			b[bit/8] |= mask
+		} else {
+			b[bit/8] &^= mask
+		}
+	}
+}
+
+// instanceTypes collects the discriminator values inferred from the objects set on interface attributes. Discriminators
+// left as zero are filled in from InstanceType, and the ones already set are verified against InstanceForContext
+// or InstanceFor, which must return an instance of the same type, or no instance for Raw.
+func (e *encoder) instanceTypes(v reflect.Value, vf *valueFields) (map[string]reflect.Value, error) {
+	m, _ := v.Interface().(InstanceType)
+	spec := tlvSpec(v)
+	if m == nil && (spec == nil || spec.Registry == nil) {
+		return nil, nil
+	}
+	var types map[string]reflect.Value
+	for i := 0; i < len(*vf); i++ {
+		f := (*vf)[i]
+		fv := v.Field(i)
+		if fv.Kind() != reflect.Interface || fv.IsNil() {
+			continue
+		}
+		var name string
+		var value uint64
+		if m != nil {
+			name, value = m.InstanceType(f.Name, fv.Interface())
+		}
+		if name == "" && spec != nil && spec.Registry != nil && f.tlv == _tlvValue {
+			// verified against the Registry when the type is not registered for the value
+			if _, tf := tlvField(v, vf, _tlvType); tf != nil {
+				name = tf.Name
+				value, _ = spec.typeOf(fv.Interface())
+			}
+		}
+		if name == "" {
+			continue
+		}
+		dv := v.FieldByName(name)
+		if !isUnsigned(dv) {
+			return nil, &MarshalInstanceTypeError{Struct: v.Type().Name(), Field: f.Name, Discriminator: name, Type: fv.Elem().Type()}
+		}
+		if dv.Uint() == 0 {
+			tv := reflect.New(dv.Type()).Elem()
+			tv.SetUint(value)
+			if types == nil {
+				types = make(map[string]reflect.Value)
+			}
+			types[name] = tv
+			continue
+		}
+		// the decoder keeps the bytes as Raw when there is no instance for the discriminator
+		instance := e.instanceFor(v, f)
+		if instance == nil {
+			instance = &Raw{}
+		}
+		if reflect.TypeOf(instance) != fv.Elem().Type() {
+			return nil, &MarshalInstanceTypeError{Struct: v.Type().Name(), Field: f.Name, Discriminator: name, Type: fv.Elem().Type()}
+		}
+	}
+	return types, nil
+}
+
+// instanceFor returns the instance the decoder would create for the field
+func (e *encoder) instanceFor(v reflect.Value, f *field) interface{} {
+	if spec := tlvSpec(v); spec != nil && f.tlv == _tlvValue {
+		vf := getStructFields(v)
+		if tv, _ := tlvField(v, vf, _tlvType); tv.IsValid() {
+			if instance := spec.instance(tv.Uint()); instance != nil {
+				return instance
+			}
+		}
+	}
+	offset := uint64(e.Len())
+	switch m := v.Interface().(type) {
+	case InstanceForContext:
+		return m.InstanceForContext(e.ctx.at(offset, offset), f.Name)
+	case InstanceFor:
+		return m.InstanceFor(f.Name)
+	}
+	return nil
+}
+
+// isUnsigned returns true when the discriminator is a valid unsigned integer field
+func isUnsigned(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package packet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type byteOrder struct {
+	Magic   uint32
+	Version uint16
+	Flags   uint8  `packet:"length=4b"`
+	Kind    uint8  `packet:"length=4b"`
+	Offset  uint32 `packet:"length=3B"`
+	Time    uint64
+}
+
+func TestByteOrder(t *testing.T) {
+	data := []byte{
+		0xd4, 0xc3, 0xb2, 0xa1, 0x02, 0x00, 0x12, 0x03, 0x02, 0x01,
+		0x08, 0x07, 0x06, 0x05, 0x04, 0x03, 0x02, 0x01,
+	}
+	expected := &byteOrder{Magic: 0xa1b2c3d4, Version: 2, Flags: 1, Kind: 2, Offset: 0x010203, Time: 0x0102030405060708}
+
+	o := &byteOrder{}
+	assert.NoError(t, UnmarshalOptions{ByteOrder: binary.LittleEndian}.Unmarshal(data, o))
+	assert.Equal(t, expected, o)
+
+	b, err := MarshalOptions{ByteOrder: binary.LittleEndian}.Marshal(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	// big endian by default
+	b, err = Marshal(expected)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xa1, 0xb2, 0xc3, 0xd4, 0x00, 0x02, 0x12, 0x01, 0x02, 0x03}, b[:10])
+	o = &byteOrder{}
+	assert.NoError(t, Unmarshal(b, o))
+	assert.Equal(t, expected, o)
+}
+
+// discriminated names the discriminator by the value, which is not always an unsigned integer field
+type discriminated struct {
+	Kind  string `packet:"length=1B"`
+	Type  uint8
+	Value interface{}
+}
+
+func (d discriminated) InstanceType(fieldname string, value interface{}) (string, uint64) {
+	switch value.(type) {
+	case *Raw:
+		return "Type", 1
+	case *tlvNumber:
+		return "Kind", 1
+	}
+	return "Missing", 1
+}
+
+func TestMarshalDiscriminator(t *testing.T) {
+	b, err := Marshal(&discriminated{Kind: "a", Value: &Raw{0xaa}})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{'a', 0x01, 0xaa}, b)
+
+	for _, value := range []interface{}{&tlvNumber{}, &byteOrder{}} {
+		_, err := Marshal(&discriminated{Kind: "a", Value: value})
+		assert.IsType(t, &MarshalInstanceTypeError{}, err, "discriminator for %T", value)
+	}
+}
@@ -2,6 +2,7 @@ package packet
 
 import (
 	"reflect"
+	"strconv"
 )
 
 // UnmarshalPtrError error from expected pointer not found
@@ -61,3 +62,38 @@ type UnmarshalUnexpectedEnd struct {
 func (e *UnmarshalUnexpectedEnd) Error() string {
 	return "packet: premature end of data for " + e.Struct + "." + e.Field
 }
+
+// A MarshalInstanceTypeError describes a discriminator value that does not match the object set on the attribute,
+// or a discriminator named by InstanceType that is not an unsigned integer field of the struct
+type MarshalInstanceTypeError struct {
+	Struct        string       // name of the struct type containing the field
+	Field         string       // name of the field holding the object
+	Discriminator string       // name of the discriminator field
+	Type          reflect.Type // type of the object
+}
+
+func (e *MarshalInstanceTypeError) Error() string {
+	return "packet: " + e.Struct + "." + e.Discriminator + " does not match " + e.Type.String() + " in Go struct field " + e.Struct + "." + e.Field
+}
+
+// An UnmarshalDepthError describes structs nested beyond the limit, e.g. tunnels carrying tunnels
+type UnmarshalDepthError struct {
+	Struct   string // name of the struct type over the limit
+	Offset   int64  // error occurred after reading Offset bytes
+	MaxDepth int    // the limit of nesting
+}
+
+func (e *UnmarshalDepthError) Error() string {
+	return "packet: " + e.Struct + " nested beyond the max depth of " + strconv.Itoa(e.MaxDepth)
+}
+
+// A MarshalLengthError describes a TLV length that does not fit in the length field
+type MarshalLengthError struct {
+	Struct string // name of the struct type containing the field
+	Field  string // name of the value field
+	Length uint64 // length computed for the value
+}
+
+func (e *MarshalLengthError) Error() string {
+	return "packet: length " + strconv.FormatUint(e.Length, 10) + " of Go struct field " + e.Struct + "." + e.Field + " overflows the length field"
+}
@@ -5,16 +5,14 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // ValueFields is an array of fields
 type valueFields []*field
 
-var _structFields map[string]*valueFields
-
-func init() {
-	_structFields = make(map[string]*valueFields)
-}
+// _structFields caches the fields by struct type, as types from different packages can share the same name
+var _structFields sync.Map
 
 const tagName = "packet"
 
@@ -32,7 +30,7 @@ func (u unit) String() string {
 	case _byte:
 		return "B"
 	}
-	return "uncognized unit(" + string(int(u)) + ")"
+	return "uncognized unit(" + strconv.Itoa(int(u)) + ")"
 }
 
 type length struct {
@@ -50,15 +48,58 @@ type when struct {
 	value     uint64
 }
 
+// met returns true when the field holding the condition satisfies it, booleans being 1 or 0
+func (w *when) met(parent reflect.Value) bool {
+	fv := parent.FieldByName(w.field)
+	var v uint64
+	switch fv.Kind() {
+	case reflect.Bool:
+		if fv.Bool() {
+			v = 1
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v = fv.Uint()
+	default:
+		panic(fmt.Errorf("(when) field %s.%s not found or not supported", parent.Type().Name(), w.field))
+	}
+	switch w.condition {
+	case "eq":
+		return v == w.value
+	case "ne":
+		return v != w.value
+	case "gt":
+		return v > w.value
+	case "lt":
+		return v < w.value
+	case "and":
+		return v&w.value != 0
+	}
+	panic(fmt.Errorf("(when) condition %s not supported", w.condition))
+}
+
+// whens conditions separated by (|), of which any has to be met
+type whens []*when
+
+func (ws whens) met(parent reflect.Value) bool {
+	for _, w := range ws {
+		if w.met(parent) {
+			return true
+		}
+	}
+	return false
+}
+
 type field struct {
 	reflect.StructField
 	length     *length
-	when       *when
+	when       whens
 	lengthfrom string
 	countfrom  string
+	tlv        tlv
 	f          struct {
 		lengthfor  bool
 		lengthrest bool
+		sizefor    bool
 	}
 }
 
@@ -66,18 +107,17 @@ func getStructFields(v reflect.Value) *valueFields {
 	if v.Kind() != reflect.Struct {
 		panic(fmt.Errorf("%s not a struct", v.Kind()))
 	}
-	vf, ok := _structFields[v.Type().Name()]
-
-	if !ok {
-		vf = &valueFields{}
-		t := v.Type()
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			*vf = append(*vf, newField(f))
-		}
-		_structFields[v.Type().Name()] = vf
+	t := v.Type()
+	if vf, ok := _structFields.Load(t); ok {
+		return vf.(*valueFields)
+	}
+	vf := &valueFields{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		*vf = append(*vf, newField(f))
 	}
-	return vf
+	actual, _ := _structFields.LoadOrStore(t, vf)
+	return actual.(*valueFields)
 }
 
 func newField(_f reflect.StructField) *field {
@@ -111,19 +151,32 @@ func (f *field) populateTag() {
 					panic(fmt.Errorf("not handling unit spec (%s)", value))
 				}
 			case "when":
-				c := strings.Split(value, "-")
-				if len(c) != 3 {
-					panic(fmt.Errorf("(when) should have 3 words seprated by (-), but got %s", value))
+				for _, condition := range strings.Split(value, "|") {
+					c := strings.Split(condition, "-")
+					if len(c) != 3 {
+						panic(fmt.Errorf("(when) should have 3 words seprated by (-), but got %s", condition))
+					}
+					v, err := strconv.ParseUint(c[2], 0, 64)
+					if err != nil {
+						panic(fmt.Errorf("failed to parse: %s", err))
+					}
+					f.when = append(f.when, &when{field: c[0], condition: c[1], value: v})
 				}
-				v, err := strconv.ParseUint(c[2], 10, 32)
-				if err != nil {
-					panic(fmt.Errorf("failed to parse: %s", err))
-				}
-				f.when = &when{field: c[0], condition: c[1], value: v}
 			case "lengthfrom":
 				f.lengthfrom = value
 			case "countfrom":
 				f.countfrom = value
+			case "tlv":
+				switch value {
+				case "type":
+					f.tlv = _tlvType
+				case "length":
+					f.tlv = _tlvLength
+				case "value":
+					f.tlv = _tlvValue
+				default:
+					panic(fmt.Errorf("not handling tlv spec (%s)", value))
+				}
 			default:
 				panic(fmt.Errorf("unrecogned header (%s)", head))
 			}
@@ -133,6 +186,8 @@ func (f *field) populateTag() {
 				f.f.lengthfor = true
 			case "lengthrest":
 				f.f.lengthrest = true
+			case "sizefor":
+				f.f.sizefor = true
 			default:
 				panic(fmt.Errorf("unrecogned header (%s) tags (%s)", head, tags))
 			}
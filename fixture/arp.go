@@ -0,0 +1,47 @@
+package fixture
+
+import (
+	"fmt"
+	"net"
+)
+
+// ARPOperation operation of ARP
+type ARPOperation uint16
+
+const (
+	ARPRequest ARPOperation = 1
+	ARPReply   ARPOperation = 2
+)
+
+func (o ARPOperation) String() string {
+	switch o {
+	case ARPRequest:
+		return "request"
+	case ARPReply:
+		return "reply"
+	}
+	return fmt.Sprintf("ARPOperation(%d)", int(o))
+}
+
+// ARP address resolution protocol as per https://tools.ietf.org/html/rfc826, the addresses being sized by
+// HardwareLength and ProtocolLength, IPv4 addresses are expected in the 4 bytes form
+type ARP struct {
+	HardwareType   uint16
+	ProtocolType   EtherType
+	HardwareLength uint8
+	ProtocolLength uint8
+	Operation      ARPOperation
+	SenderHardware net.HardwareAddr `packet:"lengthfor"`
+	SenderProtocol net.IP           `packet:"lengthfor"`
+	TargetHardware net.HardwareAddr `packet:"lengthfor"`
+	TargetProtocol net.IP           `packet:"lengthfor"`
+}
+
+// LengthFor returns the length in bytes for the provided field
+func (a ARP) LengthFor(fieldname string) uint64 {
+	switch fieldname {
+	case "SenderHardware", "TargetHardware":
+		return uint64(a.HardwareLength)
+	}
+	return uint64(a.ProtocolLength)
+}
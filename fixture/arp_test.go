@@ -0,0 +1,31 @@
+package fixture
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARP(t *testing.T) {
+	mac := net.HardwareAddr{0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77}
+	b := serialize(t,
+		&layers.Ethernet{SrcMAC: mac, DstMAC: layers.EthernetBroadcast, EthernetType: layers.EthernetTypeARP},
+		&layers.ARP{AddrType: layers.LinkTypeEthernet, Protocol: layers.EthernetTypeIPv4, HwAddressSize: 6, ProtAddressSize: 4,
+			Operation: layers.ARPRequest, SourceHwAddress: mac, SourceProtAddress: []byte{192, 168, 0, 1},
+			DstHwAddress: []byte{0, 0, 0, 0, 0, 0}, DstProtAddress: []byte{192, 168, 0, 2}})
+
+	ether := &EthernetII{}
+	assert.NoError(t, packet.Unmarshal(b, ether), "failed to decode")
+	assert.Equal(t, &ARP{HardwareType: 1, ProtocolType: _IPv4, HardwareLength: 6, ProtocolLength: 4, Operation: ARPRequest,
+		SenderHardware: mac, SenderProtocol: net.IP{192, 168, 0, 1},
+		TargetHardware: net.HardwareAddr{0, 0, 0, 0, 0, 0}, TargetProtocol: net.IP{192, 168, 0, 2}}, ether.Body)
+	assert.Equal(t, "request", ether.Body.(*ARP).Operation.String())
+
+	encoded, err := packet.Marshal(&EthernetII{Source: ether.Source, Dest: ether.Dest, Body: ether.Body})
+	assert.NoError(t, err, "failed to encode")
+	// the frame is padded to the minimum size
+	assert.Equal(t, b[:42], encoded, "same data")
+}
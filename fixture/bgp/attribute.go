@@ -0,0 +1,140 @@
+package bgp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/nickchen/packet"
+)
+
+// As4PathAttribute AS4_PATH attribute segment, with 4-octet AS numbers regardless of the session, as per
+// https://tools.ietf.org/html/rfc6793#section-3
+type As4PathAttribute struct {
+	Type  AsPathType
+	Count uint8
+	List  []ASN4 `packet:"countfrom=Count"`
+}
+
+// As4AggregatorAttribute AS4_AGGREGATOR attribute, with 4-octet AS number regardless of the session
+type As4AggregatorAttribute struct {
+	AS     ASN4
+	Origin net.IP `packet:"length=4B"`
+}
+
+// MultiExitDiscAttribute multi exit discriminator BGP attribute
+type MultiExitDiscAttribute struct {
+	MED uint32
+}
+
+// OriginatorIDAttribute originator id BGP attribute as per https://tools.ietf.org/html/rfc4456#section-8
+type OriginatorIDAttribute struct {
+	OriginatorID IPAddr `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (OriginatorIDAttribute) LengthFor(fieldname string) uint64 {
+	return uint64(4)
+}
+
+// ClusterListAttribute cluster list BGP attribute as per https://tools.ietf.org/html/rfc4456#section-8
+type ClusterListAttribute struct {
+	ClusterID IPAddr `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (ClusterListAttribute) LengthFor(fieldname string) uint64 {
+	return uint64(4)
+}
+
+// ExtendedCommunityType type of extended community, with the IANA authority and transitive bits
+type ExtendedCommunityType uint8
+
+const (
+	// TwoOctetASSpecific extended community with 2-octet AS as global administrator
+	TwoOctetASSpecific ExtendedCommunityType = 0x00
+	// IPv4AddressSpecific extended community with IPv4 address as global administrator
+	IPv4AddressSpecific ExtendedCommunityType = 0x01
+	// FourOctetASSpecific extended community with 4-octet AS as global administrator, https://tools.ietf.org/html/rfc5668
+	FourOctetASSpecific ExtendedCommunityType = 0x02
+	// Opaque extended community
+	Opaque ExtendedCommunityType = 0x03
+	// NonTransitive bit of the extended community type
+	NonTransitive ExtendedCommunityType = 0x40
+)
+
+// Extended community sub-types for route target and route origin
+const (
+	RouteTarget uint8 = 0x02
+	RouteOrigin uint8 = 0x03
+)
+
+// ExtendedCommunityAttribute extended communities BGP attribute as per https://tools.ietf.org/html/rfc4360
+type ExtendedCommunityAttribute struct {
+	Type    ExtendedCommunityType
+	Subtype uint8
+	Value   [6]byte
+}
+
+func (c ExtendedCommunityAttribute) String() string {
+	prefix := fmt.Sprintf("%d:%d:", c.Type, c.Subtype)
+	switch c.Subtype {
+	case RouteTarget:
+		prefix = "rt:"
+	case RouteOrigin:
+		prefix = "soo:"
+	}
+	switch c.Type &^ NonTransitive {
+	case TwoOctetASSpecific:
+		return fmt.Sprintf("%s%d:%d", prefix, binary.BigEndian.Uint16(c.Value[0:2]), binary.BigEndian.Uint32(c.Value[2:6]))
+	case IPv4AddressSpecific:
+		return fmt.Sprintf("%s%s:%d", prefix, net.IP(c.Value[0:4]), binary.BigEndian.Uint16(c.Value[4:6]))
+	case FourOctetASSpecific:
+		return fmt.Sprintf("%s%d:%d", prefix, binary.BigEndian.Uint32(c.Value[0:4]), binary.BigEndian.Uint16(c.Value[4:6]))
+	}
+	return fmt.Sprintf("%s%x", prefix, c.Value[:])
+}
+
+// LargeCommunityAttribute large communities BGP attribute as per https://tools.ietf.org/html/rfc8092
+type LargeCommunityAttribute struct {
+	GlobalAdmin uint32
+	LocalData1  uint32
+	LocalData2  uint32
+}
+
+func (c LargeCommunityAttribute) String() string {
+	return fmt.Sprintf("%d:%d:%d", c.GlobalAdmin, c.LocalData1, c.LocalData2)
+}
+
+// AIGPType type of AIGP TLV
+type AIGPType uint8
+
+const (
+	// AIGPMetricType AIGP TLV with the accumulated metric
+	AIGPMetricType AIGPType = 1
+)
+
+// AIGPAttribute accumulated IGP metric BGP attribute as per https://tools.ietf.org/html/rfc7311#section-3,
+// Length includes the Type and Length fields.
+type AIGPAttribute struct {
+	Type   AIGPType    `packet:"tlv=type"`
+	Length uint16      `packet:"tlv=length"`
+	Value  interface{} `packet:"tlv=value"`
+}
+
+// AIGPMetric value of AIGP TLV
+type AIGPMetric struct {
+	Metric uint64
+}
+
+var aigpSpec = &packet.TLVSpec{
+	Inclusive: true,
+	Registry: map[uint64]interface{}{
+		uint64(AIGPMetricType): &AIGPMetric{},
+	},
+}
+
+// TLV returns the spec of AIGP TLVs
+func (a AIGPAttribute) TLV() *packet.TLVSpec {
+	return aigpSpec
+}
@@ -0,0 +1,140 @@
+package bgp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAttributes MED, ORIGINATOR_ID, CLUSTER_LIST, EXTENDED_COMMUNITIES, LARGE_COMMUNITY and AIGP
+var testAttributes = []byte{
+	0x80, 0x04, 0x04, 0x00, 0x00, 0x00, 0x64,
+	0x80, 0x09, 0x04, 0xc0, 0xa8, 0x00, 0x01,
+	0x80, 0x0a, 0x08, 0xc0, 0xa8, 0x00, 0x01, 0xc0, 0xa8, 0x00, 0x02,
+	0xc0, 0x10, 0x10, 0x00, 0x02, 0xfd, 0xe8, 0x00, 0x00, 0x00, 0x64, 0x01, 0x03, 0xc0, 0xa8, 0x00, 0x01, 0x00, 0x0a,
+	0xc0, 0x20, 0x0c, 0x00, 0x00, 0xfd, 0xe8, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02,
+	0x80, 0x1a, 0x0b, 0x01, 0x00, 0x0b, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x64,
+}
+
+var attributes = &[]PathAttribute{
+	PathAttribute{
+		Flags:  Optional,
+		Code:   MultiExitDisc,
+		Length: 4,
+		Data:   &MultiExitDiscAttribute{MED: 100},
+	},
+	PathAttribute{
+		Flags:  Optional,
+		Code:   OriginatorID,
+		Length: 4,
+		Data:   &OriginatorIDAttribute{OriginatorID: []byte{0xc0, 0xa8, 0x00, 0x01}},
+	},
+	PathAttribute{
+		Flags:  Optional,
+		Code:   ClusterList,
+		Length: 8,
+		Data: &[]ClusterListAttribute{
+			{ClusterID: []byte{0xc0, 0xa8, 0x00, 0x01}},
+			{ClusterID: []byte{0xc0, 0xa8, 0x00, 0x02}},
+		},
+	},
+	PathAttribute{
+		Flags:  Optional | Transitive,
+		Code:   ExtendedCommunity,
+		Length: 16,
+		Data: &[]ExtendedCommunityAttribute{
+			{Type: TwoOctetASSpecific, Subtype: RouteTarget, Value: [6]byte{0xfd, 0xe8, 0x00, 0x00, 0x00, 0x64}},
+			{Type: IPv4AddressSpecific, Subtype: RouteOrigin, Value: [6]byte{0xc0, 0xa8, 0x00, 0x01, 0x00, 0x0a}},
+		},
+	},
+	PathAttribute{
+		Flags:  Optional | Transitive,
+		Code:   LargeCommunity,
+		Length: 12,
+		Data:   &[]LargeCommunityAttribute{{GlobalAdmin: 65000, LocalData1: 1, LocalData2: 2}},
+	},
+	PathAttribute{
+		Flags:  Optional,
+		Code:   AIGP,
+		Length: 11,
+		Data:   &[]AIGPAttribute{{Type: AIGPMetricType, Length: 11, Value: &AIGPMetric{Metric: 100}}},
+	},
+}
+
+func TestBGPAttributes(t *testing.T) {
+	checkAttributes(t, NewSession(), attributes, testAttributes)
+
+	communities := *(*attributes)[3].Data.(*[]ExtendedCommunityAttribute)
+	assert.Equal(t, "rt:65000:100", communities[0].String())
+	assert.Equal(t, "soo:192.168.0.1:10", communities[1].String())
+	large := *(*attributes)[4].Data.(*[]LargeCommunityAttribute)
+	assert.Equal(t, "65000:1:2", large[0].String())
+
+	// type and length inclusive of the header inferred from the metric
+	b, err := packet.Marshal(&[]AIGPAttribute{{Value: &AIGPMetric{Metric: 100}}})
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testAttributes[len(testAttributes)-11:], b, "same data")
+}
+
+// testAS4Attributes AS_PATH and AGGREGATOR with 2 bytes ASN, along with AS4_PATH and AS4_AGGREGATOR
+var testAS4Attributes = []byte{
+	0x40, 0x02, 0x06, 0x02, 0x02, 0x5b, 0xa0, 0x5b, 0xa0,
+	0xc0, 0x07, 0x06, 0x5b, 0xa0, 0xc0, 0xa8, 0x00, 0x01,
+	0xc0, 0x11, 0x0a, 0x02, 0x02, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01,
+	0xc0, 0x12, 0x08, 0x00, 0x01, 0x00, 0x00, 0xc0, 0xa8, 0x00, 0x01,
+}
+
+var as4Attributes = &[]PathAttribute{
+	PathAttribute{
+		Flags:  Transitive,
+		Code:   AsPath,
+		Length: 6,
+		Data:   &[]AsPathAttribute{{Type: AsSequence, Count: 2, List: []ASN4{23456, 23456}}},
+	},
+	PathAttribute{
+		Flags:  Optional | Transitive,
+		Code:   Aggregator,
+		Length: 6,
+		Data:   &AggregatorAttribute{AS: 23456, Origin: net.IP{0xc0, 0xa8, 0x00, 0x01}},
+	},
+	PathAttribute{
+		Flags:  Optional | Transitive,
+		Code:   As4Path,
+		Length: 10,
+		Data:   &[]As4PathAttribute{{Type: AsSequence, Count: 2, List: []ASN4{65536, 65537}}},
+	},
+	PathAttribute{
+		Flags:  Optional | Transitive,
+		Code:   As4Aggregator,
+		Length: 8,
+		Data:   &As4AggregatorAttribute{AS: 65536, Origin: net.IP{0xc0, 0xa8, 0x00, 0x01}},
+	},
+}
+
+func TestBGPAS4Attributes(t *testing.T) {
+	checkAttributes(t, NewSession(), as4Attributes, testAS4Attributes)
+
+	// codes and lengths inferred from the data
+	inferred := []PathAttribute{}
+	for _, a := range *as4Attributes {
+		inferred = append(inferred, PathAttribute{Flags: a.Flags, Data: a.Data})
+	}
+	b, err := packet.Marshal(&inferred)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testAS4Attributes, b, "same data")
+}
+
+// testUnknownAttribute optional transitive attribute of an unassigned code, followed by ATOMIC_AGGREGATE
+var testUnknownAttribute = []byte{
+	0xc0, 0xfe, 0x03, 0x01, 0x02, 0x03,
+	0x40, 0x06, 0x00,
+}
+
+func TestBGPUnknownAttribute(t *testing.T) {
+	checkAttributes(t, NewSession(), &[]PathAttribute{
+		PathAttribute{Flags: Optional | Transitive, Code: 0xfe, Length: 3, Data: &packet.Raw{0x01, 0x02, 0x03}},
+		PathAttribute{Flags: Transitive, Code: AtomicAggregate},
+	}, testUnknownAttribute)
+}
@@ -0,0 +1,163 @@
+package bgp
+
+import (
+	"fmt"
+
+	"github.com/nickchen/packet"
+)
+
+// CapabilityCode capability code as registered in https://www.iana.org/assignments/capability-codes
+type CapabilityCode uint8
+
+const (
+	// MultiprotocolCapability for multiprotocol extensions, https://tools.ietf.org/html/rfc4760
+	MultiprotocolCapability CapabilityCode = 1
+	// RouteRefreshCapability for ROUTE-REFRESH message, https://tools.ietf.org/html/rfc2918
+	RouteRefreshCapability CapabilityCode = 2
+	// ExtendedNextHopCapability for next hop of a different address family, https://tools.ietf.org/html/rfc8950
+	ExtendedNextHopCapability CapabilityCode = 5
+	// ExtendedMessageCapability for messages up to 65535 bytes, https://tools.ietf.org/html/rfc8654
+	ExtendedMessageCapability CapabilityCode = 6
+	// GracefulRestartCapability for graceful restart, https://tools.ietf.org/html/rfc4724
+	GracefulRestartCapability CapabilityCode = 64
+	// FourOctetASCapability for 4-octet AS numbers, https://tools.ietf.org/html/rfc6793
+	FourOctetASCapability CapabilityCode = 65
+	// AddPathCapability for multiple paths of a prefix, https://tools.ietf.org/html/rfc7911
+	AddPathCapability CapabilityCode = 69
+	// EnhancedRouteRefreshCapability for enhanced route refresh, https://tools.ietf.org/html/rfc7313
+	EnhancedRouteRefreshCapability CapabilityCode = 70
+	// FQDNCapability for hostname and domain name of the speaker, https://tools.ietf.org/html/draft-walton-bgp-hostname-capability
+	FQDNCapability CapabilityCode = 73
+)
+
+func (c CapabilityCode) String() string {
+	switch c {
+	case MultiprotocolCapability:
+		return "MULTIPROTOCOL"
+	case RouteRefreshCapability:
+		return "ROUTE_REFRESH"
+	case ExtendedNextHopCapability:
+		return "EXTENDED_NEXTHOP"
+	case ExtendedMessageCapability:
+		return "EXTENDED_MESSAGE"
+	case GracefulRestartCapability:
+		return "GRACEFUL_RESTART"
+	case FourOctetASCapability:
+		return "FOUR_OCTET_AS"
+	case AddPathCapability:
+		return "ADD_PATH"
+	case EnhancedRouteRefreshCapability:
+		return "ENHANCED_ROUTE_REFRESH"
+	case FQDNCapability:
+		return "FQDN"
+	}
+	return fmt.Sprintf("CapabilityCode(%d)", int(c))
+}
+
+// Capability defines the capability advertised in the optional parameter as per https://tools.ietf.org/html/rfc5492#section-4.
+// Value is nil for capabilities without value, e.g. ROUTE_REFRESH.
+type Capability struct {
+	Code   CapabilityCode `packet:"tlv=type"`
+	Length uint8          `packet:"tlv=length"`
+	Value  interface{}    `packet:"tlv=value"`
+}
+
+var capabilitySpec = &packet.TLVSpec{
+	Registry: map[uint64]interface{}{
+		uint64(MultiprotocolCapability):   &Multiprotocol{},
+		uint64(ExtendedNextHopCapability): &[]ExtendedNextHop{},
+		uint64(GracefulRestartCapability): &GracefulRestart{},
+		uint64(FourOctetASCapability):     &FourOctetAS{},
+		uint64(AddPathCapability):         &[]AddPath{},
+		uint64(FQDNCapability):            &FQDN{},
+	},
+}
+
+// TLV returns the TLV spec of capabilities
+func (c Capability) TLV() *packet.TLVSpec {
+	return capabilitySpec
+}
+
+// Multiprotocol capability value, with the address family supported by the speaker
+type Multiprotocol struct {
+	AFI      AFI
+	Reserved uint8
+	SAFI     SAFI
+}
+
+// ExtendedNextHop capability value, with the address family of next hop for the NLRI address family
+type ExtendedNextHop struct {
+	AFI        AFI
+	SAFI       uint16
+	NexthopAFI AFI
+}
+
+// GracefulRestart capability value
+type GracefulRestart struct {
+	Restart      bool
+	Notification bool
+	Reserved     uint8  `packet:"length=2b"`
+	Time         uint16 `packet:"length=12b"`
+	Families     []GracefulRestartFamily
+}
+
+// GracefulRestartFlag flags for the address family in graceful restart capability
+type GracefulRestartFlag uint8
+
+const (
+	// ForwardingState forwarding state has been preserved for the address family
+	ForwardingState GracefulRestartFlag = 0x80
+)
+
+// GracefulRestartFamily address family in graceful restart capability
+type GracefulRestartFamily struct {
+	AFI   AFI
+	SAFI  SAFI
+	Flags GracefulRestartFlag
+}
+
+// FourOctetAS capability value, with the 4-octet AS number of the speaker
+type FourOctetAS struct {
+	AS ASN4
+}
+
+// AddPathMode whether the speaker is able to send and/or receive multiple paths
+type AddPathMode uint8
+
+const (
+	// AddPathReceive able to receive multiple paths
+	AddPathReceive AddPathMode = 1 << iota
+	// AddPathSend able to send multiple paths
+	AddPathSend
+)
+
+// matches returns true when either speaker is able to send multiple paths, and its peer is able to receive them
+func (m AddPathMode) matches(peer AddPathMode) bool {
+	return m&AddPathSend != 0 && peer&AddPathReceive != 0 || m&AddPathReceive != 0 && peer&AddPathSend != 0
+}
+
+// AddPath capability value for one address family
+type AddPath struct {
+	AFI         AFI
+	SAFI        SAFI
+	SendReceive AddPathMode
+}
+
+// FQDN capability value, with the hostname and domain name of the speaker
+type FQDN struct {
+	HostnameLength uint8
+	Hostname       string `packet:"lengthfor"`
+	DomainLength   uint8
+	Domain         string `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (f FQDN) LengthFor(fieldname string) uint64 {
+	switch fieldname {
+	case "Hostname":
+		return uint64(f.HostnameLength)
+	case "Domain":
+		return uint64(f.DomainLength)
+	}
+	return 0
+}
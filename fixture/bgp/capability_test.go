@@ -0,0 +1,100 @@
+package bgp
+
+import (
+	"testing"
+)
+
+// testBGPOpenCapabilitiesMessage OPEN with capabilities
+var testBGPOpenCapabilitiesMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x59, 0x01, 0x04, 0xfd, 0xe8, 0x00, 0xb4, 0xc0, 0xa8, 0x00, 0x01, 0x3c, 0x02, 0x3a, 0x01,
+	0x04, 0x00, 0x01, 0x00, 0x01, 0x01, 0x04, 0x00, 0x02, 0x00, 0x01, 0x02, 0x00, 0x46, 0x00, 0x06,
+	0x00, 0x41, 0x04, 0x00, 0x00, 0xfd, 0xe8, 0x40, 0x06, 0x80, 0x78, 0x00, 0x01, 0x01, 0x80, 0x45,
+	0x04, 0x00, 0x01, 0x01, 0x01, 0x05, 0x06, 0x00, 0x01, 0x00, 0x01, 0x00, 0x02, 0x49, 0x0a, 0x02,
+	0x72, 0x31, 0x06, 0x65, 0x78, 0x2e, 0x63, 0x6f, 0x6d,
+}
+
+var openCapabilitiesMessage = &Message{
+	Marker: _16ByteMaker,
+	Length: 89,
+	Type:   _Open,
+	Body: &Open{
+		Version:        4,
+		AS:             65000,
+		Holdtime:       180,
+		RouterID:       0xc0a80001,
+		OptionalLength: 60,
+		Optional: []OptionalParameter{
+			OptionalParameter{
+				Type:   CapabilitiesParameter,
+				Length: 58,
+				Data: &[]Capability{
+					Capability{Code: MultiprotocolCapability, Length: 4, Value: &Multiprotocol{AFI: IPv4, SAFI: Unicast}},
+					Capability{Code: MultiprotocolCapability, Length: 4, Value: &Multiprotocol{AFI: IPv6, SAFI: Unicast}},
+					Capability{Code: RouteRefreshCapability},
+					Capability{Code: EnhancedRouteRefreshCapability},
+					Capability{Code: ExtendedMessageCapability},
+					Capability{Code: FourOctetASCapability, Length: 4, Value: &FourOctetAS{AS: 65000}},
+					Capability{Code: GracefulRestartCapability, Length: 6, Value: &GracefulRestart{
+						Restart: true,
+						Time:    120,
+						Families: []GracefulRestartFamily{
+							GracefulRestartFamily{AFI: IPv4, SAFI: Unicast, Flags: ForwardingState},
+						},
+					}},
+					Capability{Code: AddPathCapability, Length: 4, Value: &[]AddPath{
+						AddPath{AFI: IPv4, SAFI: Unicast, SendReceive: AddPathReceive},
+					}},
+					Capability{Code: ExtendedNextHopCapability, Length: 6, Value: &[]ExtendedNextHop{
+						ExtendedNextHop{AFI: IPv4, SAFI: uint16(Unicast), NexthopAFI: IPv6},
+					}},
+					Capability{Code: FQDNCapability, Length: 10, Value: &FQDN{
+						HostnameLength: 2,
+						Hostname:       "r1",
+						DomainLength:   6,
+						Domain:         "ex.com",
+					}},
+				},
+			},
+		},
+	},
+}
+
+func TestBGPOpenCapabilities(t *testing.T) {
+	checkBGP(t, openCapabilitiesMessage, testBGPOpenCapabilitiesMessage, _Open)
+}
+
+// testBGPOpenExtendedMessage OPEN with extended optional parameters length
+var testBGPOpenExtendedMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x29, 0x01, 0x04, 0xfd, 0xe8, 0x00, 0xb4, 0xc0, 0xa8, 0x00, 0x01, 0xff, 0xff, 0x00, 0x09,
+	0x02, 0x00, 0x06, 0x41, 0x04, 0x00, 0x00, 0xfd, 0xe8,
+}
+
+var openExtendedMessage = &Message{
+	Marker: _16ByteMaker,
+	Length: 41,
+	Type:   _Open,
+	Body: &Open{
+		Version:        4,
+		AS:             65000,
+		Holdtime:       180,
+		RouterID:       0xc0a80001,
+		OptionalLength: 255,
+		ExtendedType:   255,
+		ExtendedLength: 9,
+		Optional: []OptionalParameter{
+			OptionalParameter{
+				Type:   CapabilitiesParameter,
+				Length: 6,
+				Data: &[]Capability{
+					Capability{Code: FourOctetASCapability, Length: 4, Value: &FourOctetAS{AS: 65000}},
+				},
+			},
+		},
+	},
+}
+
+func TestBGPOpenExtended(t *testing.T) {
+	checkBGP(t, openExtendedMessage, testBGPOpenExtendedMessage, _Open)
+}
@@ -0,0 +1,65 @@
+package bgp
+
+import "fmt"
+
+// AFI Address Family Identifier as registered in https://www.iana.org/assignments/address-family-numbers
+type AFI uint16
+
+const (
+	// IPv4 address family
+	IPv4 AFI = 1
+	// IPv6 address family
+	IPv6 AFI = 2
+)
+
+func (a AFI) String() string {
+	switch a {
+	case IPv4:
+		return "IPv4"
+	case IPv6:
+		return "IPv6"
+	}
+	return fmt.Sprintf("AFI(%d)", int(a))
+}
+
+// SAFI Subsequent Address Family Identifier as registered in https://www.iana.org/assignments/safi-namespace
+type SAFI uint8
+
+const (
+	// Unicast forwarding
+	Unicast SAFI = 1
+	// Multicast forwarding
+	Multicast SAFI = 2
+	// LabeledUnicast NLRI with MPLS labels, https://tools.ietf.org/html/rfc8277
+	LabeledUnicast SAFI = 4
+	// MPLSVPN NLRI with MPLS labels and route distinguisher, https://tools.ietf.org/html/rfc4364
+	MPLSVPN SAFI = 128
+)
+
+func (s SAFI) String() string {
+	switch s {
+	case Unicast:
+		return "Unicast"
+	case Multicast:
+		return "Multicast"
+	case LabeledUnicast:
+		return "LabeledUnicast"
+	case MPLSVPN:
+		return "MPLSVPN"
+	}
+	return fmt.Sprintf("SAFI(%d)", int(s))
+}
+
+func (s SAFI) vpn() bool {
+	return s == MPLSVPN
+}
+
+// Family is the pair of AFI and SAFI
+type Family struct {
+	AFI  AFI
+	SAFI SAFI
+}
+
+func (f Family) String() string {
+	return f.AFI.String() + "/" + f.SAFI.String()
+}
@@ -4,12 +4,15 @@ import (
 	"fmt"
 	"net"
 	"strings"
+
+	"github.com/nickchen/packet"
 )
 
 var _16ByteMaker = [16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
 	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
 
-// Message Border Gateway Protocol (BGP) Message
+// Message Border Gateway Protocol (BGP) Message. packet.Unmarshal decodes any Marker, the callers must call
+// Validate to check it, which is done by Session.Unmarshal and StreamReader.
 type Message struct {
 	Marker [16]byte
 	Length uint16
@@ -25,6 +28,7 @@ const (
 	_Update
 	_Notification
 	_Keepalive
+	_RouteRefresh
 )
 
 func (t MessageType) String() string {
@@ -37,6 +41,8 @@ func (t MessageType) String() string {
 		return "NOTIFICATION"
 	case _Keepalive:
 		return "KEEPALIVE"
+	case _RouteRefresh:
+		return "ROUTE-REFRESH"
 	}
 	return fmt.Sprintf("Unknown(MessageType=%d)", int(t))
 }
@@ -52,55 +58,198 @@ func (bgp Message) InstanceFor(fieldname string) interface{} {
 		return &Notification{}
 	case _Keepalive:
 		return &Keepalive{}
+	case _RouteRefresh:
+		return &RouteRefreshMessage{}
 	}
 	return nil
 }
 
+// InstanceType interface implementation to provide the message type for the body
+func (bgp Message) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	switch body.(type) {
+	case *Open:
+		return "Type", uint64(_Open)
+	case *Update:
+		return "Type", uint64(_Update)
+	case *Notification:
+		return "Type", uint64(_Notification)
+	case *Keepalive:
+		return "Type", uint64(_Keepalive)
+	case *RouteRefreshMessage:
+		return "Type", uint64(_RouteRefresh)
+	}
+	return "", 0
+}
+
 // HeaderSize the header size of BGP messages
 const HeaderSize = 19
 
+// NewMessage returns the message for the body, with the Marker and Length set, the body being encoded with the
+// session capabilities, or without any when the session is nil. It returns packet.MarshalLengthError when the
+// message is longer than Session.MaxLength.
+func NewMessage(s *Session, body interface{}) (*Message, error) {
+	b, err := packet.MarshalOptions{Session: s}.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if length := HeaderSize + len(b); length > s.MaxLength() {
+		return nil, &packet.MarshalLengthError{Struct: "Message", Field: "Body", Length: uint64(length)}
+	}
+	m := &Message{Marker: _16ByteMaker, Length: uint16(HeaderSize + len(b)), Body: body}
+	if _, t := m.InstanceType("Body", body); t != 0 {
+		m.Type = MessageType(t)
+	}
+	return m, nil
+}
+
+// MarkerError describes a message with a marker other than all ones
+type MarkerError struct {
+	Marker [16]byte
+}
+
+func (e *MarkerError) Error() string {
+	return fmt.Sprintf("bgp: invalid marker %x", e.Marker[:])
+}
+
+// Validate checks the marker of the message, which must be all ones as per https://tools.ietf.org/html/rfc4271#section-4.1
+func (bgp Message) Validate() error {
+	if bgp.Marker != _16ByteMaker {
+		return &MarkerError{Marker: bgp.Marker}
+	}
+	return nil
+}
+
 // LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field. This returns the length of next message in number of bytes.
 func (bgp Message) LengthFor(fieldname string) uint64 {
 	return uint64(bgp.Length - HeaderSize)
 }
 
-// Open message of BGP
+// Open message of BGP. ExtendedType and ExtendedLength are only present for the extended optional parameters length,
+// as per https://tools.ietf.org/html/rfc9072, where OptionalLength and ExtendedType are both 255.
 type Open struct {
 	Version        uint8
 	AS             uint16
 	Holdtime       uint16
 	RouterID       uint32
 	OptionalLength uint8
+	ExtendedType   uint8               `packet:"lengthfor"`
+	ExtendedLength uint16              `packet:"lengthfor"`
 	Optional       []OptionalParameter `packet:"lengthfor"`
 }
 
-// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
-func (o Open) LengthFor(fieldname string) uint64 {
+// extendedOptional marker for the extended optional parameters length
+const extendedOptional = 255
+
+// Extended returns true when the optional parameters use the extended length
+func (o Open) Extended() bool {
+	return o.OptionalLength == extendedOptional && o.ExtendedType == extendedOptional
+}
+
+// LengthForContext implementation of the LengthForContext interface, which returns length in bytes for the provided field
+func (o Open) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	switch fieldname {
+	case "ExtendedType":
+		if o.OptionalLength != extendedOptional {
+			return 0
+		}
+		// peek at the type of first parameter when decoding
+		if b := ctx.Bytes(); o.ExtendedType == extendedOptional || (len(b) > 0 && b[0] == extendedOptional) {
+			return 1
+		}
+	case "ExtendedLength":
+		if o.Extended() {
+			return 2
+		}
+	case "Optional":
+		if o.Extended() {
+			return uint64(o.ExtendedLength)
+		}
+		return uint64(o.OptionalLength)
+	}
 	return 0
 }
 
+// Capabilities returns all the capabilities advertised in the optional parameters
+func (o Open) Capabilities() []Capability {
+	var capabilities []Capability
+	for _, p := range o.Optional {
+		if c, ok := p.Data.(*[]Capability); ok {
+			capabilities = append(capabilities, *c...)
+		}
+	}
+	return capabilities
+}
+
+// ParameterType type of the optional parameter in BGP OPEN message
+type ParameterType uint8
+
+const (
+	// CapabilitiesParameter optional parameter for capabilities advertisement as per https://tools.ietf.org/html/rfc5492
+	CapabilitiesParameter ParameterType = 2
+)
+
 // OptionalParameter defines the optional parameter in BGP OPEN message as per https://tools.ietf.org/html/rfc4271#section-4.2
+// Length is 2 bytes when the OPEN message uses extended optional parameters length, or 1 byte otherwise.
 type OptionalParameter struct {
-	Type   uint8
-	Length uint8
-	Data   interface{} `packet:"lengthfor"`
+	Type   ParameterType `packet:"tlv=type"`
+	Length uint16        `packet:"tlv=length,lengthfor"`
+	Data   interface{}   `packet:"tlv=value"`
+}
+
+var optionalParameterSpec = &packet.TLVSpec{}
+
+// TLV returns the TLV spec of optional parameters
+func (p OptionalParameter) TLV() *packet.TLVSpec {
+	return optionalParameterSpec
+}
+
+// InstanceFor interface implementation to provide struct for the data
+func (p OptionalParameter) InstanceFor(fieldname string) interface{} {
+	switch p.Type {
+	case CapabilitiesParameter:
+		return &[]Capability{}
+	}
+	return nil
+}
+
+// InstanceType interface implementation to provide the parameter type for the data
+func (p OptionalParameter) InstanceType(fieldname string, data interface{}) (string, uint64) {
+	switch data.(type) {
+	case *[]Capability:
+		return "Type", uint64(CapabilitiesParameter)
+	}
+	return "", 0
+}
+
+// LengthForContext implementation of the LengthForContext interface, which returns the width in bytes of Length,
+// 2 bytes for the extended optional parameters
+func (p OptionalParameter) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	if o, ok := ctx.Parent(1).(*Open); ok && o.Extended() {
+		return 2
+	}
+	return 1
 }
 
 // PrefixSpec is a compact container for route specification in BGP messages,
-// which consist of Length for how many bits are in a network Prefix
+// which consist of Length for how many bits are in a network Prefix.
+// PathID is only present when ADD-PATH is negotiated for the address family, as per https://tools.ietf.org/html/rfc7911#section-3
 type PrefixSpec struct {
+	PathID uint32 `packet:"lengthfor"`
 	Length uint8
 	Prefix []byte `packet:"lengthfor"`
 }
 
-// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field.
+// LengthForContext implementation of the LengthForContext interface, which returns length in bytes for the provided field.
 // Length of Prefix is for number of bits.
-func (p PrefixSpec) LengthFor(fieldname string) uint64 {
-	l := uint64(p.Length / 8)
-	if p.Length%8 != 0 {
-		l++
+func (p PrefixSpec) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	switch fieldname {
+	case "PathID":
+		if sessionFor(ctx).addPath(familyFor(ctx)) {
+			return 4
+		}
+		return 0
 	}
-	return l
+	return prefixLength(uint64(p.Length))
 }
 
 // AttributeFlag flags for Path Attributes
@@ -132,8 +281,13 @@ const (
 	Community
 	OriginatorID
 	ClusterList
-	MPReachNLRI   AttributeType = 14
-	MPUnreachNLRI AttributeType = 15
+	MPReachNLRI       AttributeType = 14
+	MPUnreachNLRI     AttributeType = 15
+	ExtendedCommunity AttributeType = 16
+	As4Path           AttributeType = 17
+	As4Aggregator     AttributeType = 18
+	AIGP              AttributeType = 26
+	LargeCommunity    AttributeType = 32
 )
 
 // String conversions for CapabilityCode
@@ -163,6 +317,16 @@ func (t AttributeType) String() string {
 		return "MP_REACH_NLRI"
 	case MPUnreachNLRI:
 		return "MP_UNREACH_NLRI"
+	case ExtendedCommunity:
+		return "EXTENDED_COMMUNITIES"
+	case As4Path:
+		return "AS4_PATH"
+	case As4Aggregator:
+		return "AS4_AGGREGATOR"
+	case AIGP:
+		return "AIGP"
+	case LargeCommunity:
+		return "LARGE_COMMUNITY"
 	default:
 		return fmt.Sprintf("AttributeType(%d)", int(t))
 	}
@@ -172,9 +336,16 @@ func (t AttributeType) String() string {
 // Length is 2 bytes when (Flags & 0x01) != 0, or 1 byte otherwise.
 type PathAttribute struct {
 	Flags  AttributeFlag
-	Code   AttributeType
-	Length uint16      `packet:"lengthfor"`
-	Data   interface{} `packet:"lengthfor"`
+	Code   AttributeType `packet:"tlv=type"`
+	Length uint16        `packet:"tlv=length,lengthfor"`
+	Data   interface{}   `packet:"tlv=value"`
+}
+
+var pathAttributeSpec = &packet.TLVSpec{}
+
+// TLV returns the TLV spec of path attributes, the attribute structs being provided by InstanceForContext
+func (p PathAttribute) TLV() *packet.TLVSpec {
+	return pathAttributeSpec
 }
 
 // OriginCode origin code
@@ -219,16 +390,20 @@ const (
 // ASN BGP Autonomous System Number
 type ASN uint16
 
+// ASN4 BGP Autonomous System Number of 4 octets as per https://tools.ietf.org/html/rfc6793, encoded as 2 octets
+// unless 4-octet AS is negotiated for the session
+type ASN4 uint32
+
 // AsPathAttribute AS path attribute
 type AsPathAttribute struct {
 	Type  AsPathType
 	Count uint8
-	List  []ASN `packet:"lengthfor"`
+	List  []ASN4 `packet:"countfrom=Count,sizefor"`
 }
 
-// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
-func (a AsPathAttribute) LengthFor(fieldname string) uint64 {
-	return uint64(a.Count * 2)
+// LengthForContext implementation of the LengthForContext interface, which returns the size in bytes of each ASN
+func (a AsPathAttribute) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	return sessionFor(ctx).asnSize()
 }
 
 func (f AttributeFlag) String() string {
@@ -279,8 +454,13 @@ type LocalPrefAttribute struct {
 
 // AggregatorAttribute aggregator BGP attribute
 type AggregatorAttribute struct {
-	AS     uint16
-	Origin net.IP
+	AS     ASN4   `packet:"lengthfor"`
+	Origin net.IP `packet:"length=4B"`
+}
+
+// LengthForContext implementation of the LengthForContext interface, which returns length in bytes for the provided field
+func (a AggregatorAttribute) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	return sessionFor(ctx).asnSize()
 }
 
 // CommunityAttribute community BGP attribute
@@ -288,7 +468,15 @@ type CommunityAttribute struct {
 	Attribute uint32
 }
 
-// InstanceFor interface implementation to provide struct for the body
+// InstanceForContext interface implementation to provide struct for the abbreviated MP_REACH_NLRI when the session asks for it
+func (p PathAttribute) InstanceForContext(ctx *packet.Context, fieldname string) interface{} {
+	if p.Code == MPReachNLRI && sessionFor(ctx).abbreviatedMPReach() {
+		return &MPReachNLRIAbbreviated{}
+	}
+	return p.InstanceFor(fieldname)
+}
+
+// InstanceFor interface implementation to provide struct for the attribute data
 func (p PathAttribute) InstanceFor(fieldname string) interface{} {
 	switch p.Code {
 	case Origin:
@@ -297,31 +485,82 @@ func (p PathAttribute) InstanceFor(fieldname string) interface{} {
 		return &[]AsPathAttribute{}
 	case Nexthop:
 		return &NexthopAttribute{}
+	case MultiExitDisc:
+		return &MultiExitDiscAttribute{}
 	case LocalPref:
 		return &LocalPrefAttribute{}
 	case Aggregator:
 		return &AggregatorAttribute{}
 	case Community:
 		return &[]CommunityAttribute{}
-	case AtomicAggregate:
-		return nil
+	case OriginatorID:
+		return &OriginatorIDAttribute{}
+	case ClusterList:
+		return &[]ClusterListAttribute{}
+	case ExtendedCommunity:
+		return &[]ExtendedCommunityAttribute{}
+	case As4Path:
+		return &[]As4PathAttribute{}
+	case As4Aggregator:
+		return &As4AggregatorAttribute{}
+	case AIGP:
+		return &[]AIGPAttribute{}
+	case LargeCommunity:
+		return &[]LargeCommunityAttribute{}
+	case MPReachNLRI:
+		return &MPReachNLRIAttribute{}
+	case MPUnreachNLRI:
+		return &MPUnreachNLRIAttribute{}
 	}
-	b := make([]byte, p.Length)
-	return &b
+	// ATOMIC_AGGREGATE has no data, and the data of unknown attributes is kept as Raw
+	return nil
 }
 
-// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+// InstanceType interface implementation to provide the attribute code for the data
+func (p PathAttribute) InstanceType(fieldname string, data interface{}) (string, uint64) {
+	switch data.(type) {
+	case *OriginAttribute:
+		return "Code", uint64(Origin)
+	case *[]AsPathAttribute:
+		return "Code", uint64(AsPath)
+	case *NexthopAttribute:
+		return "Code", uint64(Nexthop)
+	case *MultiExitDiscAttribute:
+		return "Code", uint64(MultiExitDisc)
+	case *LocalPrefAttribute:
+		return "Code", uint64(LocalPref)
+	case *AggregatorAttribute:
+		return "Code", uint64(Aggregator)
+	case *[]CommunityAttribute:
+		return "Code", uint64(Community)
+	case *OriginatorIDAttribute:
+		return "Code", uint64(OriginatorID)
+	case *[]ClusterListAttribute:
+		return "Code", uint64(ClusterList)
+	case *[]ExtendedCommunityAttribute:
+		return "Code", uint64(ExtendedCommunity)
+	case *[]As4PathAttribute:
+		return "Code", uint64(As4Path)
+	case *As4AggregatorAttribute:
+		return "Code", uint64(As4Aggregator)
+	case *[]AIGPAttribute:
+		return "Code", uint64(AIGP)
+	case *[]LargeCommunityAttribute:
+		return "Code", uint64(LargeCommunity)
+	case *MPReachNLRIAttribute, *MPReachNLRIAbbreviated:
+		return "Code", uint64(MPReachNLRI)
+	case *MPUnreachNLRIAttribute:
+		return "Code", uint64(MPUnreachNLRI)
+	}
+	return "", 0
+}
+
+// LengthFor implementation of the LengthFor interface, which returns the width in bytes of Length
 func (p PathAttribute) LengthFor(fieldname string) uint64 {
-	switch fieldname {
-	case "Length":
-		if (p.Flags & ExtendedLength) != 0 {
-			return 2
-		}
-		return 1
-	case "Data":
-		return uint64(p.Length)
+	if (p.Flags & ExtendedLength) != 0 {
+		return 2
 	}
-	return 0
+	return 1
 }
 
 // Update message struct as defined in https://tools.ietf.org/html/rfc4271#section-4.3
@@ -344,16 +583,6 @@ func (u Update) LengthFor(fieldname string) uint64 {
 	return 0
 }
 
-// ErrorType BGP error message type as defined in https://tools.ietf.org/html/rfc4271#section-4.5
-type ErrorType uint8
-
-// Notification struct from RFC 4271 - Section 4.5
-type Notification struct {
-	Code    ErrorType
-	Subcode uint8
-	Content []byte
-}
-
 // Keepalive is an intentionally empty struct as defined in https://tools.ietf.org/html/rfc4271#section-4.4
 type Keepalive struct {
 }
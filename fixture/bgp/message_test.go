@@ -116,7 +116,7 @@ var updateMessage = &Message{
 				Code:   AsPath,
 				Length: 4,
 				Data: &[]AsPathAttribute{
-					AsPathAttribute{Type: AsSequence, Count: 1, List: []ASN{ASN(65000)}},
+					AsPathAttribute{Type: AsSequence, Count: 1, List: []ASN4{ASN4(65000)}},
 				},
 			},
 			PathAttribute{
@@ -187,8 +187,8 @@ var comboMessage = &[]Message{
 					Code:   AsPath,
 					Length: 10,
 					Data: &[]AsPathAttribute{
-						AsPathAttribute{Type: AsSet, Count: 2, List: []ASN{ASN(500), ASN(500)}},
-						AsPathAttribute{Type: AsSequence, Count: 1, List: []ASN{ASN(65211)}},
+						AsPathAttribute{Type: AsSet, Count: 2, List: []ASN4{ASN4(500), ASN4(500)}},
+						AsPathAttribute{Type: AsSequence, Count: 1, List: []ASN4{ASN4(65211)}},
 					},
 				},
 				PathAttribute{
@@ -234,13 +234,13 @@ var comboMessage = &[]Message{
 					Flags:  Optional,
 					Code:   OriginatorID,
 					Length: 4,
-					Data:   &[]byte{0xc0, 0xa8, 0x00, 0x0f},
+					Data:   &OriginatorIDAttribute{OriginatorID: []byte{0xc0, 0xa8, 0x00, 0x0f}},
 				},
 				PathAttribute{
 					Flags:  Optional,
 					Code:   ClusterList,
 					Length: 4,
-					Data:   &[]byte{0xc0, 0xa8, 0x00, 0xfa},
+					Data:   &[]ClusterListAttribute{{ClusterID: []byte{0xc0, 0xa8, 0x00, 0xfa}}},
 				},
 			},
 			NLRI: []PrefixSpec{
@@ -270,8 +270,8 @@ var comboMessage = &[]Message{
 					Code:   AsPath,
 					Length: 10,
 					Data: &[]AsPathAttribute{
-						AsPathAttribute{Type: AsSet, Count: 2, List: []ASN{ASN(500), ASN(500)}},
-						AsPathAttribute{Type: AsSequence, Count: 1, List: []ASN{ASN(65211)}},
+						AsPathAttribute{Type: AsSet, Count: 2, List: []ASN4{ASN4(500), ASN4(500)}},
+						AsPathAttribute{Type: AsSequence, Count: 1, List: []ASN4{ASN4(65211)}},
 					},
 				},
 				PathAttribute{
@@ -317,13 +317,13 @@ var comboMessage = &[]Message{
 					Flags:  Optional,
 					Code:   OriginatorID,
 					Length: 4,
-					Data:   &[]byte{0xc0, 0xa8, 0x00, 0x0f},
+					Data:   &OriginatorIDAttribute{OriginatorID: []byte{0xc0, 0xa8, 0x00, 0x0f}},
 				},
 				PathAttribute{
 					Flags:  Optional,
 					Code:   ClusterList,
 					Length: 4,
-					Data:   &[]byte{0xc0, 0xa8, 0x00, 0xfa},
+					Data:   &[]ClusterListAttribute{{ClusterID: []byte{0xc0, 0xa8, 0x00, 0xfa}}},
 				},
 			},
 			NLRI: []PrefixSpec{
@@ -345,3 +345,47 @@ func BenchmarkComboMessage(b *testing.B) {
 		_, _ = packet.Marshal(comboMessage)
 	}
 }
+
+func TestBGPMarshalInstanceType(t *testing.T) {
+	m := &Message{
+		Marker: _16ByteMaker,
+		Length: 61,
+		Body: &Update{
+			PathAttributeLength: 18,
+			PathAttributes: []PathAttribute{
+				PathAttribute{Flags: Transitive, Length: 1, Data: &OriginAttribute{Origin: IBGP}},
+				PathAttribute{Flags: Transitive, Length: 4, Data: &[]AsPathAttribute{
+					AsPathAttribute{Type: AsSequence, Count: 1, List: []ASN4{ASN4(65000)}},
+				}},
+				PathAttribute{Flags: Transitive, Length: 4, Data: &NexthopAttribute{Nexthop: IPAddr(net.ParseIP("192.168.86.100").To4())}},
+			},
+			NLRI: updateMessage.Body.(*Update).NLRI,
+		},
+	}
+	b, err := packet.Marshal(m)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testBGPUpdateMessage, b, "type and codes filled from body")
+
+	m.Type = _Open
+	_, err = packet.Marshal(m)
+	assert.IsType(t, &packet.MarshalInstanceTypeError{}, err, "OPEN type with UPDATE body")
+}
+
+// testBGPUnknownMessage has a message of unknown type followed by a KEEPALIVE
+var testBGPUnknownMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x17, 0xc8, 0x01, 0x02, 0x03, 0x04, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x13, 0x04,
+}
+
+func TestBGPUnknownMessage(t *testing.T) {
+	checkBGP(t, &[]Message{
+		Message{
+			Marker: _16ByteMaker,
+			Type:   MessageType(200),
+			Length: 23,
+			Body:   &packet.Raw{0x01, 0x02, 0x03, 0x04},
+		},
+		*keepAliveMessage,
+	}, testBGPUnknownMessage, MessageType(200))
+}
@@ -0,0 +1,238 @@
+package bgp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/nickchen/packet"
+)
+
+// RouteDistinguisher route distinguisher for VPN routes as per https://tools.ietf.org/html/rfc4364#section-4.2
+type RouteDistinguisher [8]byte
+
+func (rd RouteDistinguisher) String() string {
+	switch binary.BigEndian.Uint16(rd[0:2]) {
+	case 0:
+		return fmt.Sprintf("%d:%d", binary.BigEndian.Uint16(rd[2:4]), binary.BigEndian.Uint32(rd[4:8]))
+	case 1:
+		return fmt.Sprintf("%s:%d", net.IP(rd[2:6]), binary.BigEndian.Uint16(rd[6:8]))
+	case 2:
+		return fmt.Sprintf("%d:%d", binary.BigEndian.Uint32(rd[2:6]), binary.BigEndian.Uint16(rd[6:8]))
+	}
+	return fmt.Sprintf("%x", rd[:])
+}
+
+// MPReachNLRIAttribute multiprotocol reachable NLRI BGP attribute as per https://tools.ietf.org/html/rfc4760#section-3
+type MPReachNLRIAttribute struct {
+	AFI           AFI
+	SAFI          SAFI
+	NexthopLength uint8
+	Nexthop       MPNexthop `packet:"lengthfor"`
+	Reserved      uint8
+	NLRI          interface{}
+}
+
+// Family returns the address family of the NLRI
+func (r MPReachNLRIAttribute) Family() Family {
+	return Family{AFI: r.AFI, SAFI: r.SAFI}
+}
+
+// InstanceFor interface implementation to provide struct for the NLRI
+func (r MPReachNLRIAttribute) InstanceFor(fieldname string) interface{} {
+	return nlriFor(r.Family())
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (r MPReachNLRIAttribute) LengthFor(fieldname string) uint64 {
+	return uint64(r.NexthopLength)
+}
+
+// MPReachNLRIAbbreviated MP_REACH_NLRI with only the next hop, as in the RIB entries of MRT
+// https://tools.ietf.org/html/rfc6396#section-4.3.4, where the address family and NLRI come from the RIB
+type MPReachNLRIAbbreviated struct {
+	NexthopLength uint8
+	Nexthop       MPNexthop `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (r MPReachNLRIAbbreviated) LengthFor(fieldname string) uint64 {
+	return uint64(r.NexthopLength)
+}
+
+// MPNexthop next hop address of MP_REACH_NLRI, with the link-local address when the IPv6 next hop comes in pair as per
+// https://tools.ietf.org/html/rfc2545#section-3, and the route distinguishers (always zero) for VPN as per
+// https://tools.ietf.org/html/rfc4364#section-4.3.2 and https://tools.ietf.org/html/rfc4659#section-3.2
+type MPNexthop struct {
+	RD          RouteDistinguisher `packet:"lengthfor"`
+	Global      net.IP             `packet:"lengthfor"`
+	LinkLocalRD RouteDistinguisher `packet:"lengthfor"`
+	LinkLocal   net.IP             `packet:"lengthfor"`
+}
+
+// LengthForContext implementation of the LengthForContext interface, which returns length in bytes for the provided
+// field base on the next hop length and SAFI of the enclosing MP_REACH_NLRI
+func (n MPNexthop) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	var length, rd uint64
+	switch r := ctx.Parent(1).(type) {
+	case *MPReachNLRIAttribute:
+		length = uint64(r.NexthopLength)
+		if r.SAFI.vpn() {
+			rd = 8
+		}
+	case *MPReachNLRIAbbreviated:
+		length = uint64(r.NexthopLength)
+	default:
+		return 0
+	}
+	pair := length == 2*(rd+net.IPv6len)
+	switch fieldname {
+	case "RD":
+		return rd
+	case "Global":
+		if pair {
+			return net.IPv6len
+		}
+		if length > rd {
+			return length - rd
+		}
+	case "LinkLocalRD":
+		if pair {
+			return rd
+		}
+	case "LinkLocal":
+		if pair {
+			return net.IPv6len
+		}
+	}
+	return 0
+}
+
+// MPUnreachNLRIAttribute multiprotocol unreachable NLRI BGP attribute as per https://tools.ietf.org/html/rfc4760#section-4
+type MPUnreachNLRIAttribute struct {
+	AFI       AFI
+	SAFI      SAFI
+	Withdrawn interface{}
+}
+
+// Family returns the address family of the withdrawn routes
+func (u MPUnreachNLRIAttribute) Family() Family {
+	return Family{AFI: u.AFI, SAFI: u.SAFI}
+}
+
+// InstanceFor interface implementation to provide struct for the withdrawn routes
+func (u MPUnreachNLRIAttribute) InstanceFor(fieldname string) interface{} {
+	return nlriFor(u.Family())
+}
+
+// nlriFor returns the NLRI for the address family
+func nlriFor(f Family) interface{} {
+	switch f.AFI {
+	case IPv4, IPv6:
+		switch f.SAFI {
+		case Unicast, Multicast:
+			return &[]PrefixSpec{}
+		case LabeledUnicast:
+			return &[]LabeledPrefix{}
+		case MPLSVPN:
+			return &[]VPNPrefix{}
+		}
+	}
+	return nil
+}
+
+// familyFor returns the address family of the enclosing MP_REACH_NLRI or MP_UNREACH_NLRI, or IPv4 unicast otherwise
+func familyFor(ctx *packet.Context) Family {
+	for i := 0; i < ctx.Depth(); i++ {
+		switch a := ctx.Parent(i).(type) {
+		case *MPReachNLRIAttribute:
+			return a.Family()
+		case *MPUnreachNLRIAttribute:
+			return a.Family()
+		}
+	}
+	return Family{AFI: IPv4, SAFI: Unicast}
+}
+
+// Label MPLS label in labeled NLRI as per https://tools.ietf.org/html/rfc8277#section-2
+type Label struct {
+	Value         uint32 `packet:"length=20b"`
+	TC            uint8  `packet:"length=3b"`
+	BottomOfStack bool
+}
+
+// labelsLength returns the length in bytes of the labels, by looking for the bottom of stack when decoding.
+// The withdrawn label 0x800000 (https://tools.ietf.org/html/rfc8277#section-2.4) is always on its own.
+func labelsLength(ctx *packet.Context, labels []Label) uint64 {
+	b := ctx.Bytes()
+	if b == nil {
+		return uint64(3 * len(labels))
+	}
+	if len(b) >= 3 && b[0] == 0x80 && b[1] == 0 && b[2] == 0 {
+		return 3
+	}
+	for i := 2; i < len(b); i += 3 {
+		if b[i]&0x1 != 0 {
+			return uint64(i + 1)
+		}
+	}
+	return uint64(len(b))
+}
+
+// prefixLength returns the length in bytes for prefix of number of bits
+func prefixLength(bits uint64) uint64 {
+	return (bits + 7) / 8
+}
+
+// LabeledPrefix is the NLRI for labeled unicast as per https://tools.ietf.org/html/rfc8277#section-2,
+// with Length for the number of bits of labels and network Prefix.
+type LabeledPrefix struct {
+	PathID uint32 `packet:"lengthfor"`
+	Length uint8
+	Labels []Label `packet:"lengthfor"`
+	Prefix []byte  `packet:"lengthfor"`
+}
+
+// LengthForContext implementation of the LengthForContext interface, which returns length in bytes for the provided field
+func (p LabeledPrefix) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	switch fieldname {
+	case "PathID":
+		if sessionFor(ctx).addPath(familyFor(ctx)) {
+			return 4
+		}
+	case "Labels":
+		return labelsLength(ctx, p.Labels)
+	case "Prefix":
+		if bits := uint64(p.Length); bits > uint64(24*len(p.Labels)) {
+			return prefixLength(bits - uint64(24*len(p.Labels)))
+		}
+	}
+	return 0
+}
+
+// VPNPrefix is the NLRI for MPLS VPN as per https://tools.ietf.org/html/rfc4364#section-4.3.4,
+// with Length for the number of bits of labels, route distinguisher and network Prefix.
+type VPNPrefix struct {
+	PathID uint32 `packet:"lengthfor"`
+	Length uint8
+	Labels []Label `packet:"lengthfor"`
+	RD     RouteDistinguisher
+	Prefix []byte `packet:"lengthfor"`
+}
+
+// LengthForContext implementation of the LengthForContext interface, which returns length in bytes for the provided field
+func (p VPNPrefix) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	switch fieldname {
+	case "PathID":
+		if sessionFor(ctx).addPath(familyFor(ctx)) {
+			return 4
+		}
+	case "Labels":
+		return labelsLength(ctx, p.Labels)
+	case "Prefix":
+		if bits := uint64(p.Length); bits > uint64(24*len(p.Labels)+64) {
+			return prefixLength(bits - uint64(24*len(p.Labels)+64))
+		}
+	}
+	return 0
+}
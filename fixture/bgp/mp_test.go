@@ -0,0 +1,169 @@
+package bgp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkAttributes(t *testing.T, s *Session, want *[]PathAttribute, attributeBytes []byte) {
+	attributes := &[]PathAttribute{}
+	err := packet.UnmarshalOptions{Session: s}.Unmarshal(attributeBytes, attributes)
+	assert.NoError(t, err, "failed to decode")
+	assert.Empty(t, cmp.Diff(want, attributes), "diff found")
+
+	b, err := packet.MarshalOptions{Session: s}.Marshal(want)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, attributeBytes, b, "same data")
+}
+
+// testMPIPv6Attributes MP_REACH_NLRI with link-local next hop, and MP_UNREACH_NLRI for IPv6 unicast
+var testMPIPv6Attributes = []byte{
+	0x90, 0x0e, 0x00, 0x2c, 0x00, 0x02, 0x01, 0x20, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0xfe, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x30, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x01,
+	0x90, 0x0f, 0x00, 0x0a, 0x00, 0x02, 0x01, 0x30, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x02,
+}
+
+var mpIPv6Attributes = &[]PathAttribute{
+	PathAttribute{
+		Flags:  Optional | ExtendedLength,
+		Code:   MPReachNLRI,
+		Length: 44,
+		Data: &MPReachNLRIAttribute{
+			AFI:           IPv6,
+			SAFI:          Unicast,
+			NexthopLength: 32,
+			Nexthop: MPNexthop{
+				Global:    net.ParseIP("2001:db8::1"),
+				LinkLocal: net.ParseIP("fe80::1"),
+			},
+			NLRI: &[]PrefixSpec{
+				PrefixSpec{Length: 48, Prefix: []byte{0x20, 0x01, 0x0d, 0xb8, 0x00, 0x01}},
+			},
+		},
+	},
+	PathAttribute{
+		Flags:  Optional | ExtendedLength,
+		Code:   MPUnreachNLRI,
+		Length: 10,
+		Data: &MPUnreachNLRIAttribute{
+			AFI:  IPv6,
+			SAFI: Unicast,
+			Withdrawn: &[]PrefixSpec{
+				PrefixSpec{Length: 48, Prefix: []byte{0x20, 0x01, 0x0d, 0xb8, 0x00, 0x02}},
+			},
+		},
+	},
+}
+
+func TestMPIPv6(t *testing.T) {
+	checkAttributes(t, nil, mpIPv6Attributes, testMPIPv6Attributes)
+}
+
+// testMPLabeledAttributes MP_REACH_NLRI for IPv4 labeled unicast, VPNv4 and VPNv6
+var testMPLabeledAttributes = []byte{
+	0x90, 0x0e, 0x00, 0x10, 0x00, 0x01, 0x04, 0x04, 0x0a, 0x00, 0x00, 0x01, 0x00, 0x30, 0x00, 0x06,
+	0x41, 0x0a, 0x01, 0x02,
+	0x90, 0x0e, 0x00, 0x20, 0x00, 0x01, 0x80, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x0a, 0x00, 0x00, 0x01, 0x00, 0x70, 0x00, 0x06, 0x41, 0x00, 0x00, 0xfd, 0xe8, 0x00, 0x00, 0x00,
+	0x64, 0x0a, 0x01, 0x02,
+	0x90, 0x0e, 0x00, 0x31, 0x00, 0x02, 0x80, 0x18, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+	0x00, 0x98, 0x00, 0x06, 0x41, 0x00, 0x00, 0xfd, 0xe8, 0x00, 0x00, 0x00, 0x64, 0x20, 0x01, 0x0d,
+	0xb8, 0x00, 0x01, 0x00, 0x02,
+}
+
+var testRD = RouteDistinguisher{0x00, 0x00, 0xfd, 0xe8, 0x00, 0x00, 0x00, 0x64}
+
+var mpLabeledAttributes = &[]PathAttribute{
+	PathAttribute{
+		Flags:  Optional | ExtendedLength,
+		Code:   MPReachNLRI,
+		Length: 16,
+		Data: &MPReachNLRIAttribute{
+			AFI:           IPv4,
+			SAFI:          LabeledUnicast,
+			NexthopLength: 4,
+			Nexthop:       MPNexthop{Global: net.IP{10, 0, 0, 1}},
+			NLRI: &[]LabeledPrefix{
+				LabeledPrefix{
+					Length: 48,
+					Labels: []Label{Label{Value: 100, BottomOfStack: true}},
+					Prefix: []byte{10, 1, 2},
+				},
+			},
+		},
+	},
+	PathAttribute{
+		Flags:  Optional | ExtendedLength,
+		Code:   MPReachNLRI,
+		Length: 32,
+		Data: &MPReachNLRIAttribute{
+			AFI:           IPv4,
+			SAFI:          MPLSVPN,
+			NexthopLength: 12,
+			Nexthop:       MPNexthop{Global: net.IP{10, 0, 0, 1}},
+			NLRI: &[]VPNPrefix{
+				VPNPrefix{
+					Length: 112,
+					Labels: []Label{Label{Value: 100, BottomOfStack: true}},
+					RD:     testRD,
+					Prefix: []byte{10, 1, 2},
+				},
+			},
+		},
+	},
+	PathAttribute{
+		Flags:  Optional | ExtendedLength,
+		Code:   MPReachNLRI,
+		Length: 49,
+		Data: &MPReachNLRIAttribute{
+			AFI:           IPv6,
+			SAFI:          MPLSVPN,
+			NexthopLength: 24,
+			Nexthop:       MPNexthop{Global: net.ParseIP("2001:db8::1")},
+			NLRI: &[]VPNPrefix{
+				VPNPrefix{
+					Length: 152,
+					Labels: []Label{Label{Value: 100, BottomOfStack: true}},
+					RD:     testRD,
+					Prefix: []byte{0x20, 0x01, 0x0d, 0xb8, 0x00, 0x01, 0x00, 0x02},
+				},
+			},
+		},
+	},
+}
+
+func TestMPLabeled(t *testing.T) {
+	checkAttributes(t, nil, mpLabeledAttributes, testMPLabeledAttributes)
+	assert.Equal(t, "65000:100", testRD.String())
+}
+
+// testMPAddPathAttributes MP_UNREACH_NLRI for IPv6 unicast with path identifier
+var testMPAddPathAttributes = []byte{
+	0x90, 0x0f, 0x00, 0x0e, 0x00, 0x02, 0x01, 0x00, 0x00, 0x00, 0x07, 0x30, 0x20, 0x01, 0x0d, 0xb8,
+	0x00, 0x02,
+}
+
+func TestMPAddPath(t *testing.T) {
+	s := NewSession()
+	s.AddPath[Family{AFI: IPv6, SAFI: Unicast}] = true
+	checkAttributes(t, s, &[]PathAttribute{
+		PathAttribute{
+			Flags:  Optional | ExtendedLength,
+			Code:   MPUnreachNLRI,
+			Length: 14,
+			Data: &MPUnreachNLRIAttribute{
+				AFI:  IPv6,
+				SAFI: Unicast,
+				Withdrawn: &[]PrefixSpec{
+					PrefixSpec{PathID: 7, Length: 48, Prefix: []byte{0x20, 0x01, 0x0d, 0xb8, 0x00, 0x02}},
+				},
+			},
+		},
+	}, testMPAddPathAttributes)
+}
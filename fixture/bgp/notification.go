@@ -0,0 +1,337 @@
+package bgp
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/nickchen/packet"
+)
+
+// ErrorType BGP error message type as defined in https://tools.ietf.org/html/rfc4271#section-4.5
+type ErrorType uint8
+
+const (
+	// MessageHeaderError error code, subcodes are HeaderErrorSubcode
+	MessageHeaderError ErrorType = 1 + iota
+	// OpenMessageError error code, subcodes are OpenErrorSubcode
+	OpenMessageError
+	// UpdateMessageError error code, subcodes are UpdateErrorSubcode
+	UpdateMessageError
+	// HoldTimerExpired error code
+	HoldTimerExpired
+	// FiniteStateMachineError error code, subcodes are FSMErrorSubcode
+	FiniteStateMachineError
+	// Cease error code, subcodes are CeaseSubcode
+	Cease
+	// RouteRefreshMessageError error code as per https://tools.ietf.org/html/rfc7313#section-5, subcodes are RouteRefreshErrorSubcode
+	RouteRefreshMessageError
+)
+
+func (t ErrorType) String() string {
+	switch t {
+	case MessageHeaderError:
+		return "Message Header Error"
+	case OpenMessageError:
+		return "OPEN Message Error"
+	case UpdateMessageError:
+		return "UPDATE Message Error"
+	case HoldTimerExpired:
+		return "Hold Timer Expired"
+	case FiniteStateMachineError:
+		return "Finite State Machine Error"
+	case Cease:
+		return "Cease"
+	case RouteRefreshMessageError:
+		return "ROUTE-REFRESH Message Error"
+	}
+	return fmt.Sprintf("ErrorType(%d)", int(t))
+}
+
+// HeaderErrorSubcode subcodes for MessageHeaderError as per https://tools.ietf.org/html/rfc4271#section-6.1
+type HeaderErrorSubcode uint8
+
+const (
+	// ConnectionNotSynchronized header error subcode
+	ConnectionNotSynchronized HeaderErrorSubcode = 1 + iota
+	// BadMessageLength header error subcode
+	BadMessageLength
+	// BadMessageType header error subcode
+	BadMessageType
+)
+
+func (s HeaderErrorSubcode) String() string {
+	switch s {
+	case ConnectionNotSynchronized:
+		return "Connection Not Synchronized"
+	case BadMessageLength:
+		return "Bad Message Length"
+	case BadMessageType:
+		return "Bad Message Type"
+	}
+	return fmt.Sprintf("Subcode(%d)", int(s))
+}
+
+// OpenErrorSubcode subcodes for OpenMessageError as per https://tools.ietf.org/html/rfc4271#section-6.2
+// and https://tools.ietf.org/html/rfc5492#section-5
+type OpenErrorSubcode uint8
+
+const (
+	// UnsupportedVersionNumber open error subcode
+	UnsupportedVersionNumber OpenErrorSubcode = 1 + iota
+	// BadPeerAS open error subcode
+	BadPeerAS
+	// BadBGPIdentifier open error subcode
+	BadBGPIdentifier
+	// UnsupportedOptionalParameter open error subcode
+	UnsupportedOptionalParameter
+	_ // deprecated
+	// UnacceptableHoldTime open error subcode
+	UnacceptableHoldTime
+	// UnsupportedCapability open error subcode, with the unsupported capabilities as data
+	UnsupportedCapability
+)
+
+func (s OpenErrorSubcode) String() string {
+	switch s {
+	case UnsupportedVersionNumber:
+		return "Unsupported Version Number"
+	case BadPeerAS:
+		return "Bad Peer AS"
+	case BadBGPIdentifier:
+		return "Bad BGP Identifier"
+	case UnsupportedOptionalParameter:
+		return "Unsupported Optional Parameter"
+	case UnacceptableHoldTime:
+		return "Unacceptable Hold Time"
+	case UnsupportedCapability:
+		return "Unsupported Capability"
+	}
+	return fmt.Sprintf("Subcode(%d)", int(s))
+}
+
+// UpdateErrorSubcode subcodes for UpdateMessageError as per https://tools.ietf.org/html/rfc4271#section-6.3
+type UpdateErrorSubcode uint8
+
+const (
+	// MalformedAttributeList update error subcode
+	MalformedAttributeList UpdateErrorSubcode = 1 + iota
+	// UnrecognizedWellKnownAttribute update error subcode
+	UnrecognizedWellKnownAttribute
+	// MissingWellKnownAttribute update error subcode
+	MissingWellKnownAttribute
+	// AttributeFlagsError update error subcode
+	AttributeFlagsError
+	// AttributeLengthError update error subcode
+	AttributeLengthError
+	// InvalidOriginAttribute update error subcode
+	InvalidOriginAttribute
+	_ // deprecated
+	// InvalidNexthopAttribute update error subcode
+	InvalidNexthopAttribute
+	// OptionalAttributeError update error subcode
+	OptionalAttributeError
+	// InvalidNetworkField update error subcode
+	InvalidNetworkField
+	// MalformedAsPath update error subcode
+	MalformedAsPath
+)
+
+func (s UpdateErrorSubcode) String() string {
+	switch s {
+	case MalformedAttributeList:
+		return "Malformed Attribute List"
+	case UnrecognizedWellKnownAttribute:
+		return "Unrecognized Well-known Attribute"
+	case MissingWellKnownAttribute:
+		return "Missing Well-known Attribute"
+	case AttributeFlagsError:
+		return "Attribute Flags Error"
+	case AttributeLengthError:
+		return "Attribute Length Error"
+	case InvalidOriginAttribute:
+		return "Invalid ORIGIN Attribute"
+	case InvalidNexthopAttribute:
+		return "Invalid NEXT_HOP Attribute"
+	case OptionalAttributeError:
+		return "Optional Attribute Error"
+	case InvalidNetworkField:
+		return "Invalid Network Field"
+	case MalformedAsPath:
+		return "Malformed AS_PATH"
+	}
+	return fmt.Sprintf("Subcode(%d)", int(s))
+}
+
+// FSMErrorSubcode subcodes for FiniteStateMachineError as per https://tools.ietf.org/html/rfc6608#section-4
+type FSMErrorSubcode uint8
+
+const (
+	// UnexpectedMessageInOpenSent finite state machine error subcode
+	UnexpectedMessageInOpenSent FSMErrorSubcode = 1 + iota
+	// UnexpectedMessageInOpenConfirm finite state machine error subcode
+	UnexpectedMessageInOpenConfirm
+	// UnexpectedMessageInEstablished finite state machine error subcode
+	UnexpectedMessageInEstablished
+)
+
+func (s FSMErrorSubcode) String() string {
+	switch s {
+	case UnexpectedMessageInOpenSent:
+		return "Receive Unexpected Message in OpenSent State"
+	case UnexpectedMessageInOpenConfirm:
+		return "Receive Unexpected Message in OpenConfirm State"
+	case UnexpectedMessageInEstablished:
+		return "Receive Unexpected Message in Established State"
+	}
+	return fmt.Sprintf("Subcode(%d)", int(s))
+}
+
+// CeaseSubcode subcodes for Cease as per https://tools.ietf.org/html/rfc4486#section-4
+type CeaseSubcode uint8
+
+const (
+	// MaximumPrefixesReached cease subcode
+	MaximumPrefixesReached CeaseSubcode = 1 + iota
+	// AdministrativeShutdown cease subcode, with ShutdownCommunication as data
+	AdministrativeShutdown
+	// PeerDeconfigured cease subcode
+	PeerDeconfigured
+	// AdministrativeReset cease subcode, with ShutdownCommunication as data
+	AdministrativeReset
+	// ConnectionRejected cease subcode
+	ConnectionRejected
+	// OtherConfigurationChange cease subcode
+	OtherConfigurationChange
+	// ConnectionCollisionResolution cease subcode
+	ConnectionCollisionResolution
+	// OutOfResources cease subcode
+	OutOfResources
+)
+
+func (s CeaseSubcode) String() string {
+	switch s {
+	case MaximumPrefixesReached:
+		return "Maximum Number of Prefixes Reached"
+	case AdministrativeShutdown:
+		return "Administrative Shutdown"
+	case PeerDeconfigured:
+		return "Peer De-configured"
+	case AdministrativeReset:
+		return "Administrative Reset"
+	case ConnectionRejected:
+		return "Connection Rejected"
+	case OtherConfigurationChange:
+		return "Other Configuration Change"
+	case ConnectionCollisionResolution:
+		return "Connection Collision Resolution"
+	case OutOfResources:
+		return "Out of Resources"
+	}
+	return fmt.Sprintf("Subcode(%d)", int(s))
+}
+
+// RouteRefreshErrorSubcode subcodes for RouteRefreshMessageError as per https://tools.ietf.org/html/rfc7313#section-5
+type RouteRefreshErrorSubcode uint8
+
+const (
+	// InvalidMessageLength route refresh error subcode
+	InvalidMessageLength RouteRefreshErrorSubcode = 1
+)
+
+func (s RouteRefreshErrorSubcode) String() string {
+	switch s {
+	case InvalidMessageLength:
+		return "Invalid Message Length"
+	}
+	return fmt.Sprintf("Subcode(%d)", int(s))
+}
+
+// Notification struct from RFC 4271 - Section 4.5. Data replaces the former Content []byte field, it's decoded by
+// the code and subcode, and the bytes are kept as *packet.Raw otherwise.
+type Notification struct {
+	Code    ErrorType
+	Subcode uint8
+	Data    interface{}
+}
+
+// SubcodeString returns the name of the subcode for the error code
+func (n Notification) SubcodeString() string {
+	switch n.Code {
+	case MessageHeaderError:
+		return HeaderErrorSubcode(n.Subcode).String()
+	case OpenMessageError:
+		return OpenErrorSubcode(n.Subcode).String()
+	case UpdateMessageError:
+		return UpdateErrorSubcode(n.Subcode).String()
+	case FiniteStateMachineError:
+		return FSMErrorSubcode(n.Subcode).String()
+	case Cease:
+		return CeaseSubcode(n.Subcode).String()
+	case RouteRefreshMessageError:
+		return RouteRefreshErrorSubcode(n.Subcode).String()
+	}
+	return fmt.Sprintf("Subcode(%d)", int(n.Subcode))
+}
+
+func (n Notification) String() string {
+	if n.Subcode == 0 {
+		return n.Code.String()
+	}
+	return n.Code.String() + "/" + n.SubcodeString()
+}
+
+// InstanceForContext interface implementation to provide struct for the data of the subcode
+func (n Notification) InstanceForContext(ctx *packet.Context, fieldname string) interface{} {
+	if ctx.Remaining() == 0 {
+		return nil
+	}
+	switch {
+	case n.Code == OpenMessageError && OpenErrorSubcode(n.Subcode) == UnsupportedCapability:
+		return &[]Capability{}
+	case n.Code == Cease && (CeaseSubcode(n.Subcode) == AdministrativeShutdown || CeaseSubcode(n.Subcode) == AdministrativeReset):
+		return &ShutdownCommunication{}
+	}
+	return nil
+}
+
+// ShutdownCommunication the message for administrative shutdown and reset, as per https://tools.ietf.org/html/rfc8203#section-2.
+// Message is UTF-8 encoded, with Length in bytes.
+type ShutdownCommunication struct {
+	Length  uint8
+	Message string `packet:"lengthfor"`
+}
+
+// MaxShutdownCommunicationLength the maximum length in bytes of the message, as per https://tools.ietf.org/html/rfc9003#section-2
+const MaxShutdownCommunicationLength = 255
+
+// ShutdownCommunicationError describes a message that is not valid UTF-8, or longer than MaxShutdownCommunicationLength
+type ShutdownCommunicationError struct {
+	Message string
+}
+
+func (e *ShutdownCommunicationError) Error() string {
+	if !utf8.ValidString(e.Message) {
+		return "bgp: shutdown communication is not valid UTF-8"
+	}
+	return fmt.Sprintf("bgp: shutdown communication of %d bytes, longer than %d", len(e.Message), MaxShutdownCommunicationLength)
+}
+
+// NewShutdownCommunication returns a ShutdownCommunication for the message, which must be valid UTF-8 of
+// MaxShutdownCommunicationLength bytes at most
+func NewShutdownCommunication(message string) (*ShutdownCommunication, error) {
+	if len(message) > MaxShutdownCommunicationLength || !utf8.ValidString(message) {
+		return nil, &ShutdownCommunicationError{Message: message}
+	}
+	return &ShutdownCommunication{Length: uint8(len(message)), Message: message}, nil
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (s ShutdownCommunication) LengthFor(fieldname string) uint64 {
+	return uint64(s.Length)
+}
+
+// NewNotification returns the NOTIFICATION message for the error code and subcode, along with the data, which is
+// limited to the 4096 bytes of a message without extended messages.
+func NewNotification(code ErrorType, subcode uint8, data interface{}) (*Message, error) {
+	return NewMessage(nil, &Notification{Code: code, Subcode: subcode, Data: data})
+}
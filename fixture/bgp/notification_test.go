@@ -0,0 +1,74 @@
+package bgp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBGPShutdownMessage NOTIFICATION with administrative shutdown communication
+var testBGPShutdownMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x21, 0x03, 0x06, 0x02, 0x0b, 0x6d, 0x61, 0x69, 0x6e, 0x74, 0x65, 0x6e, 0x61, 0x6e, 0x63,
+	0x65,
+}
+
+// testBGPUnsupportedCapabilityMessage NOTIFICATION with unsupported capability
+var testBGPUnsupportedCapabilityMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x1b, 0x03, 0x02, 0x07, 0x41, 0x04, 0x00, 0x00, 0xfd, 0xe8,
+}
+
+// testBGPHoldTimerExpiredMessage NOTIFICATION without data
+var testBGPHoldTimerExpiredMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x15, 0x03, 0x04, 0x00,
+}
+
+// testBGPAttributeLengthErrorMessage NOTIFICATION with the erroneous attribute as data
+var testBGPAttributeLengthErrorMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x1a, 0x03, 0x03, 0x05, 0x40, 0x01, 0x02, 0x00, 0x00,
+}
+
+func TestBGPNotification(t *testing.T) {
+	communication, err := NewShutdownCommunication("maintenance")
+	assert.NoError(t, err)
+	shutdown, err := NewNotification(Cease, uint8(AdministrativeShutdown), communication)
+	assert.NoError(t, err)
+	checkBGP(t, shutdown, testBGPShutdownMessage, _Notification)
+	assert.Equal(t, "Cease/Administrative Shutdown", shutdown.Body.(*Notification).String())
+
+	unsupported, err := NewNotification(OpenMessageError, uint8(UnsupportedCapability), &[]Capability{
+		Capability{Code: FourOctetASCapability, Length: 4, Value: &FourOctetAS{AS: 65000}},
+	})
+	assert.NoError(t, err)
+	checkBGP(t, unsupported, testBGPUnsupportedCapabilityMessage, _Notification)
+	assert.Equal(t, "OPEN Message Error/Unsupported Capability", unsupported.Body.(*Notification).String())
+
+	expired, err := NewNotification(HoldTimerExpired, 0, nil)
+	assert.NoError(t, err)
+	checkBGP(t, expired, testBGPHoldTimerExpiredMessage, _Notification)
+	assert.Equal(t, "Hold Timer Expired", expired.Body.(*Notification).String())
+
+	lengthError, err := NewNotification(UpdateMessageError, uint8(AttributeLengthError), &packet.Raw{0x40, 0x01, 0x02, 0x00, 0x00})
+	assert.NoError(t, err)
+	checkBGP(t, lengthError, testBGPAttributeLengthErrorMessage, _Notification)
+	assert.Equal(t, "UPDATE Message Error/Attribute Length Error", lengthError.Body.(*Notification).String())
+}
+
+func TestShutdownCommunication(t *testing.T) {
+	communication, err := NewShutdownCommunication(strings.Repeat("a", MaxShutdownCommunicationLength))
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(MaxShutdownCommunicationLength), communication.Length)
+
+	_, err = NewShutdownCommunication(strings.Repeat("a", MaxShutdownCommunicationLength+1))
+	assert.IsType(t, &ShutdownCommunicationError{}, err, "too long")
+	assert.EqualError(t, err, "bgp: shutdown communication of 256 bytes, longer than 255")
+
+	_, err = NewShutdownCommunication("maintenance \xff")
+	assert.IsType(t, &ShutdownCommunicationError{}, err, "invalid UTF-8")
+	assert.EqualError(t, err, "bgp: shutdown communication is not valid UTF-8")
+}
@@ -0,0 +1,40 @@
+package bgp
+
+import "fmt"
+
+// RouteRefreshSubtype subtype of ROUTE-REFRESH message, as per https://tools.ietf.org/html/rfc7313#section-3.2
+type RouteRefreshSubtype uint8
+
+const (
+	// NormalRouteRefresh request as per https://tools.ietf.org/html/rfc2918
+	NormalRouteRefresh RouteRefreshSubtype = 0
+	// BeginningOfRouteRefresh demarcation of the beginning of a route refresh
+	BeginningOfRouteRefresh RouteRefreshSubtype = 1
+	// EndOfRouteRefresh demarcation of the ending of a route refresh
+	EndOfRouteRefresh RouteRefreshSubtype = 2
+)
+
+func (s RouteRefreshSubtype) String() string {
+	switch s {
+	case NormalRouteRefresh:
+		return "Route-Refresh"
+	case BeginningOfRouteRefresh:
+		return "BoRR"
+	case EndOfRouteRefresh:
+		return "EoRR"
+	}
+	return fmt.Sprintf("RouteRefreshSubtype(%d)", int(s))
+}
+
+// RouteRefreshMessage ROUTE-REFRESH message as per https://tools.ietf.org/html/rfc2918#section-3, where
+// Subtype was the reserved field before https://tools.ietf.org/html/rfc7313
+type RouteRefreshMessage struct {
+	AFI     AFI
+	Subtype RouteRefreshSubtype
+	SAFI    SAFI
+}
+
+// Family returns the address family of the route refresh
+func (r RouteRefreshMessage) Family() Family {
+	return Family{AFI: r.AFI, SAFI: r.SAFI}
+}
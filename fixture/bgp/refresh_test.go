@@ -0,0 +1,70 @@
+package bgp
+
+import (
+	"testing"
+
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBGPRouteRefreshMessages ROUTE-REFRESH for IPv4 unicast, followed by BoRR and EoRR for IPv6 unicast
+var testBGPRouteRefreshMessages = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x17, 0x05, 0x00, 0x01, 0x00, 0x01,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x17, 0x05, 0x00, 0x02, 0x01, 0x01,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x17, 0x05, 0x00, 0x02, 0x02, 0x01,
+}
+
+var routeRefreshMessages = &[]Message{
+	Message{
+		Marker: _16ByteMaker,
+		Length: 23,
+		Type:   _RouteRefresh,
+		Body:   &RouteRefreshMessage{AFI: IPv4, Subtype: NormalRouteRefresh, SAFI: Unicast},
+	},
+	Message{
+		Marker: _16ByteMaker,
+		Length: 23,
+		Type:   _RouteRefresh,
+		Body:   &RouteRefreshMessage{AFI: IPv6, Subtype: BeginningOfRouteRefresh, SAFI: Unicast},
+	},
+	Message{
+		Marker: _16ByteMaker,
+		Length: 23,
+		Type:   _RouteRefresh,
+		Body:   &RouteRefreshMessage{AFI: IPv6, Subtype: EndOfRouteRefresh, SAFI: Unicast},
+	},
+}
+
+func TestBGPRouteRefreshMessage(t *testing.T) {
+	checkBGP(t, routeRefreshMessages, testBGPRouteRefreshMessages, _RouteRefresh)
+
+	b, err := packet.Marshal(routeRefreshMessages)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testBGPRouteRefreshMessages, b, "same data")
+
+	m, err := NewMessage(nil, &RouteRefreshMessage{AFI: IPv4, SAFI: Unicast})
+	assert.NoError(t, err, "failed to create message")
+	assert.Equal(t, (*routeRefreshMessages)[0], *m, "same message")
+	assert.Equal(t, Family{AFI: IPv6, SAFI: Unicast}, (*routeRefreshMessages)[2].Body.(*RouteRefreshMessage).Family())
+	assert.Equal(t, "EoRR", EndOfRouteRefresh.String())
+}
+
+func TestBGPMarkerValidate(t *testing.T) {
+	b := append([]byte{}, testBGPRouteRefreshMessages[:23]...)
+	b[3] = 0x00
+
+	m := &Message{}
+	err := NewSession().Unmarshal(b, m)
+	if assert.IsType(t, &MarkerError{}, err, "marker error") {
+		assert.Equal(t, byte(0x00), err.(*MarkerError).Marker[3])
+	}
+	assert.NoError(t, (*routeRefreshMessages)[0].Validate(), "valid marker")
+
+	// the plain decoding leaves the marker to Validate
+	m = &Message{}
+	assert.NoError(t, packet.Unmarshal(b, m), "failed to decode")
+	assert.IsType(t, &MarkerError{}, m.Validate(), "marker error")
+}
@@ -0,0 +1,135 @@
+package bgp
+
+import (
+	"github.com/nickchen/packet"
+)
+
+// Session keeps the capabilities negotiated between BGP speakers, which decide the encoding of UPDATE messages.
+// The capabilities are learned from the observed OPEN messages, a capability is negotiated when it's advertised
+// by every OPEN observed, and ADD-PATH when the modes of the OPEN messages match. Pass the Session as packet.UnmarshalOptions (or packet.MarshalOptions) Session,
+// or use Session.Unmarshal and Session.Marshal.
+type Session struct {
+	// FourOctetAS for 4-octet AS numbers in AS_PATH and AGGREGATOR, as per https://tools.ietf.org/html/rfc6793
+	FourOctetAS bool
+	// AddPath for the address families with path identifier in NLRI, as per https://tools.ietf.org/html/rfc7911.
+	// ADD-PATH is negotiated for a family when a speaker able to send multiple paths observes a peer able to
+	// receive them, the Session being shared by both directions.
+	AddPath map[Family]bool
+	// ExtendedMessage for messages up to 65535 bytes, as per https://tools.ietf.org/html/rfc8654
+	ExtendedMessage bool
+	// AbbreviatedMPReach for MP_REACH_NLRI with only the next hop, as in the RIB entries of MRT,
+	// https://tools.ietf.org/html/rfc6396#section-4.3.4
+	AbbreviatedMPReach bool
+
+	opens int
+	// addPathModes of the last observed OPEN
+	addPathModes map[Family]AddPathMode
+}
+
+// SessionProvider is implemented by the structures embedding BGP messages or attributes, such as MRT records,
+// to provide the Session for the embedded data. The nearest provider takes precedence over the Session of the options,
+// which is used when the provider returns nil.
+type SessionProvider interface {
+	BGPSession() *Session
+}
+
+// NewSession returns a Session without any capability
+func NewSession() *Session {
+	return &Session{AddPath: make(map[Family]bool)}
+}
+
+// Observe learns the capabilities when the message is an OPEN, other messages are ignored
+func (s *Session) Observe(m *Message) {
+	o, ok := m.Body.(*Open)
+	if !ok {
+		return
+	}
+	fourOctetAS, extendedMessage := false, false
+	modes := make(map[Family]AddPathMode)
+	for _, c := range o.Capabilities() {
+		switch c.Code {
+		case FourOctetASCapability:
+			fourOctetAS = true
+		case ExtendedMessageCapability:
+			extendedMessage = true
+		case AddPathCapability:
+			if families, ok := c.Value.(*[]AddPath); ok {
+				for _, f := range *families {
+					modes[Family{AFI: f.AFI, SAFI: f.SAFI}] |= f.SendReceive
+				}
+			}
+		}
+	}
+	if s.opens == 0 {
+		s.FourOctetAS, s.ExtendedMessage = fourOctetAS, extendedMessage
+		s.AddPath = make(map[Family]bool)
+		for f, mode := range modes {
+			if mode != 0 {
+				s.AddPath[f] = true
+			}
+		}
+	} else {
+		s.FourOctetAS = s.FourOctetAS && fourOctetAS
+		s.ExtendedMessage = s.ExtendedMessage && extendedMessage
+		for f := range s.AddPath {
+			if !s.addPathModes[f].matches(modes[f]) {
+				delete(s.AddPath, f)
+			}
+		}
+	}
+	s.addPathModes = modes
+	s.opens++
+}
+
+// MaxLength returns the maximum length of a message
+func (s *Session) MaxLength() int {
+	if s != nil && s.ExtendedMessage {
+		return 65535
+	}
+	return 4096
+}
+
+// Unmarshal decodes the message with the session capabilities, and learns the capabilities from OPEN message
+func (s *Session) Unmarshal(b []byte, m *Message) error {
+	if err := (packet.UnmarshalOptions{Session: s}).Unmarshal(b, m); err != nil {
+		return err
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	s.Observe(m)
+	return nil
+}
+
+// Marshal encodes the message with the session capabilities
+func (s *Session) Marshal(m *Message) ([]byte, error) {
+	return packet.MarshalOptions{Session: s}.Marshal(m)
+}
+
+func (s *Session) asnSize() uint64 {
+	if s != nil && s.FourOctetAS {
+		return 4
+	}
+	return 2
+}
+
+func (s *Session) addPath(f Family) bool {
+	return s != nil && s.AddPath[f]
+}
+
+func (s *Session) abbreviatedMPReach() bool {
+	return s != nil && s.AbbreviatedMPReach
+}
+
+// sessionFor returns the Session from the nearest SessionProvider, or from the context, which could be nil
+func sessionFor(ctx *packet.Context) *Session {
+	for i := 0; i < ctx.Depth(); i++ {
+		if p, ok := ctx.Parent(i).(SessionProvider); ok {
+			if s := p.BGPSession(); s != nil {
+				return s
+			}
+		}
+	}
+	s, _ := ctx.Session.(*Session)
+	return s
+}
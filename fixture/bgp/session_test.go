@@ -0,0 +1,155 @@
+package bgp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBGPOpenAS4AddPathMessage OPEN with 4-octet AS and ADD-PATH for IPv4 unicast
+var testBGPOpenAS4AddPathMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x2d, 0x01, 0x04, 0x5b, 0xa0, 0x00, 0xb4, 0x0a, 0x00, 0x00, 0x01, 0x10, 0x02, 0x06, 0x41,
+	0x04, 0xfa, 0x56, 0xea, 0x00, 0x02, 0x06, 0x45, 0x04, 0x00, 0x01, 0x01, 0x03,
+}
+
+var openAS4AddPathMessage = &Message{
+	Marker: _16ByteMaker,
+	Length: 45,
+	Type:   _Open,
+	Body: &Open{
+		Version:        4,
+		AS:             23456,
+		Holdtime:       180,
+		RouterID:       0x0a000001,
+		OptionalLength: 16,
+		Optional: []OptionalParameter{
+			OptionalParameter{
+				Type:   CapabilitiesParameter,
+				Length: 6,
+				Data: &[]Capability{
+					Capability{Code: FourOctetASCapability, Length: 4, Value: &FourOctetAS{AS: 4200000000}},
+				},
+			},
+			OptionalParameter{
+				Type:   CapabilitiesParameter,
+				Length: 6,
+				Data: &[]Capability{
+					Capability{Code: AddPathCapability, Length: 4, Value: &[]AddPath{
+						AddPath{AFI: IPv4, SAFI: Unicast, SendReceive: AddPathSend | AddPathReceive},
+					}},
+				},
+			},
+		},
+	},
+}
+
+// testBGPUpdateAS4AddPathMessage UPDATE with 4-octet AS_PATH, and path identifier in NLRI
+var testBGPUpdateAS4AddPathMessage = []byte{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x37, 0x02, 0x00, 0x00, 0x00, 0x18, 0x40, 0x01, 0x01, 0x00, 0x40, 0x02, 0x0a, 0x02, 0x02,
+	0xfa, 0x56, 0xea, 0x00, 0x00, 0x00, 0xfd, 0xe8, 0x40, 0x03, 0x04, 0xc0, 0xa8, 0x56, 0x64, 0x00,
+	0x00, 0x00, 0x01, 0x18, 0x0a, 0x01, 0x03,
+}
+
+var updateAS4AddPathMessage = &Message{
+	Marker: _16ByteMaker,
+	Length: 55,
+	Type:   _Update,
+	Body: &Update{
+		PathAttributeLength: 24,
+		PathAttributes: []PathAttribute{
+			PathAttribute{Flags: Transitive, Code: Origin, Length: 1, Data: &OriginAttribute{Origin: IBGP}},
+			PathAttribute{Flags: Transitive, Code: AsPath, Length: 10, Data: &[]AsPathAttribute{
+				AsPathAttribute{Type: AsSequence, Count: 2, List: []ASN4{4200000000, 65000}},
+			}},
+			PathAttribute{Flags: Transitive, Code: Nexthop, Length: 4, Data: &NexthopAttribute{Nexthop: IPAddr(net.ParseIP("192.168.86.100").To4())}},
+		},
+		NLRI: []PrefixSpec{
+			PrefixSpec{PathID: 1, Length: 24, Prefix: []byte{0x0a, 0x01, 0x03}},
+		},
+	},
+}
+
+func TestSession(t *testing.T) {
+	s := NewSession()
+	assert.False(t, s.FourOctetAS)
+
+	open := &Message{}
+	assert.NoError(t, s.Unmarshal(testBGPOpenAS4AddPathMessage, open))
+	assert.Empty(t, cmp.Diff(openAS4AddPathMessage, open), "diff found")
+	assert.True(t, s.FourOctetAS, "learned 4-octet AS")
+	assert.True(t, s.addPath(Family{AFI: IPv4, SAFI: Unicast}), "learned ADD-PATH")
+	assert.False(t, s.ExtendedMessage)
+
+	update := &Message{}
+	assert.NoError(t, s.Unmarshal(testBGPUpdateAS4AddPathMessage, update))
+	assert.Empty(t, cmp.Diff(updateAS4AddPathMessage, update), "diff found")
+
+	b, err := s.Marshal(updateAS4AddPathMessage)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testBGPUpdateAS4AddPathMessage, b)
+
+	// peer without 4-octet AS and ADD-PATH
+	s.Observe(keepAliveMessage)
+	s.Observe(&Message{Type: _Open, Body: &Open{Version: 4, AS: 65000}})
+	assert.False(t, s.FourOctetAS, "4-octet AS not negotiated")
+	assert.False(t, s.addPath(Family{AFI: IPv4, SAFI: Unicast}), "ADD-PATH not negotiated")
+
+	b, err = s.Marshal(updateMessage)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testBGPUpdateMessage, b)
+}
+
+// addPathOpen returns OPEN with ADD-PATH for IPv4 unicast in the mode
+func addPathOpen(mode AddPathMode) *Message {
+	return &Message{Type: _Open, Body: &Open{Version: 4, AS: 65000, Optional: []OptionalParameter{
+		OptionalParameter{Type: CapabilitiesParameter, Data: &[]Capability{
+			Capability{Code: AddPathCapability, Value: &[]AddPath{AddPath{AFI: IPv4, SAFI: Unicast, SendReceive: mode}}},
+		}},
+	}}}
+}
+
+func TestSessionAddPath(t *testing.T) {
+	for _, test := range []struct {
+		local, peer AddPathMode
+		negotiated  bool
+	}{
+		{local: AddPathSend, peer: AddPathReceive, negotiated: true},
+		{local: AddPathReceive, peer: AddPathSend, negotiated: true},
+		{local: AddPathSend | AddPathReceive, peer: AddPathReceive, negotiated: true},
+		{local: AddPathSend, peer: AddPathSend},
+		{local: AddPathReceive, peer: AddPathReceive},
+		{local: AddPathSend | AddPathReceive, peer: 0},
+	} {
+		s := NewSession()
+		s.Observe(addPathOpen(test.local))
+		s.Observe(addPathOpen(test.peer))
+		assert.Equal(t, test.negotiated, s.addPath(Family{AFI: IPv4, SAFI: Unicast}), "local %d peer %d", test.local, test.peer)
+	}
+}
+
+func TestNewMessage(t *testing.T) {
+	s := NewSession()
+	assert.NoError(t, s.Unmarshal(testBGPOpenAS4AddPathMessage, &Message{}))
+	m, err := NewMessage(s, updateAS4AddPathMessage.Body)
+	assert.NoError(t, err, "failed to create message")
+	assert.Equal(t, updateAS4AddPathMessage.Length, m.Length, "sized with the session")
+	m, err = NewMessage(nil, updateAS4AddPathMessage.Body)
+	assert.NoError(t, err, "failed to create message")
+	assert.Equal(t, uint16(47), m.Length, "sized without 4-octet AS and ADD-PATH")
+
+	data := make(packet.Raw, 5000)
+	large := &Update{PathAttributes: []PathAttribute{
+		PathAttribute{Flags: Optional | Transitive | ExtendedLength, Code: 0xfe, Data: &data},
+	}}
+	_, err = NewMessage(s, large)
+	assert.Equal(t, &packet.MarshalLengthError{Struct: "Message", Field: "Body", Length: 5027}, err)
+	s.ExtendedMessage = true
+	m, err = NewMessage(s, large)
+	assert.NoError(t, err, "extended message")
+	assert.Equal(t, uint16(5027), m.Length)
+}
@@ -0,0 +1,75 @@
+package bgp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// LengthError describes a message header with a length outside of the range allowed by the session
+type LengthError struct {
+	Length uint16
+	Max    int
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("bgp: invalid message length %d, expected between %d and %d", e.Length, HeaderSize, e.Max)
+}
+
+// StreamReader splits the ordered payload of a TCP connection into BGP messages. The payload is fed in chunks
+// as they are captured, partial messages are kept until the rest of the message is fed. The messages are decoded
+// with the Session, which learns the capabilities from the OPEN messages in the stream.
+type StreamReader struct {
+	Session *Session
+
+	buffer []byte
+	err    error
+}
+
+// NewStreamReader returns a StreamReader decoding with the session, a new Session is used when it's nil
+func NewStreamReader(s *Session) *StreamReader {
+	if s == nil {
+		s = NewSession()
+	}
+	return &StreamReader{Session: s}
+}
+
+// Feed appends the chunk to the stream, and returns the messages completed by the chunk. After an error, the stream
+// can no longer be split, and the same error is returned for every following Feed.
+func (r *StreamReader) Feed(chunk []byte) ([]*Message, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.buffer = append(r.buffer, chunk...)
+	var messages []*Message
+	for len(r.buffer) >= HeaderSize {
+		m := &Message{}
+		copy(m.Marker[:], r.buffer)
+		if err := m.Validate(); err != nil {
+			r.err = err
+			return messages, err
+		}
+		length := binary.BigEndian.Uint16(r.buffer[16:18])
+		if length < HeaderSize || int(length) > r.Session.MaxLength() {
+			r.err = &LengthError{Length: length, Max: r.Session.MaxLength()}
+			return messages, r.err
+		}
+		if len(r.buffer) < int(length) {
+			break
+		}
+		if err := r.Session.Unmarshal(r.buffer[:length], m); err != nil {
+			r.err = err
+			return messages, err
+		}
+		messages = append(messages, m)
+		r.buffer = r.buffer[length:]
+	}
+	if len(r.buffer) == 0 {
+		r.buffer = nil
+	}
+	return messages, nil
+}
+
+// Buffered returns the number of bytes of the partial message waiting for the rest of the stream
+func (r *StreamReader) Buffered() int {
+	return len(r.buffer)
+}
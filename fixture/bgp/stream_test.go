@@ -0,0 +1,68 @@
+package bgp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func feedStream(t *testing.T, r *StreamReader, chunks [][]byte) []Message {
+	messages := []Message{}
+	for _, chunk := range chunks {
+		m, err := r.Feed(chunk)
+		assert.NoError(t, err, "failed to feed")
+		for _, message := range m {
+			messages = append(messages, *message)
+		}
+	}
+	assert.Equal(t, 0, r.Buffered(), "no partial message left")
+	return messages
+}
+
+func TestStreamReader(t *testing.T) {
+	b := testBGPComboMessage
+	splits := map[string][][]byte{
+		"single":       [][]byte{b},
+		"header":       [][]byte{b[:10], b[10:30], b[30:]},
+		"message":      [][]byte{b[:19], b[19:50], b[50:]},
+		"two segments": [][]byte{b[:len(b)/2], b[len(b)/2:]},
+	}
+	bytes := [][]byte{}
+	for i := range b {
+		bytes = append(bytes, b[i:i+1])
+	}
+	splits["bytes"] = bytes
+
+	for name, chunks := range splits {
+		t.Run(name, func(t *testing.T) {
+			messages := feedStream(t, NewStreamReader(nil), chunks)
+			assert.Empty(t, cmp.Diff(*comboMessage, messages), "diff found")
+		})
+	}
+}
+
+func TestStreamReaderSession(t *testing.T) {
+	b := append(append([]byte{}, testBGPOpenAS4AddPathMessage...), testBGPUpdateAS4AddPathMessage...)
+	r := NewStreamReader(nil)
+	messages := feedStream(t, r, [][]byte{b[:40], b[40:60], b[60:]})
+	assert.Empty(t, cmp.Diff([]Message{*openAS4AddPathMessage, *updateAS4AddPathMessage}, messages), "diff found")
+	assert.True(t, r.Session.FourOctetAS, "4-octet AS learned")
+}
+
+func TestStreamReaderError(t *testing.T) {
+	r := NewStreamReader(nil)
+	b := append([]byte{}, testBGPKeepaliveMessage...)
+	b[17] = 0x10
+	m, err := r.Feed(append(append([]byte{}, testBGPKeepaliveMessage...), b...))
+	assert.Len(t, m, 1, "first message")
+	assert.IsType(t, &LengthError{}, err, "length error")
+
+	_, again := r.Feed(testBGPKeepaliveMessage)
+	assert.Equal(t, err, again, "same error")
+
+	b = append([]byte{}, testBGPKeepaliveMessage...)
+	b[0] = 0x00
+	_, err = NewStreamReader(nil).Feed(b)
+	assert.IsType(t, &MarkerError{}, err, "marker error")
+}
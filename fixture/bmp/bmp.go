@@ -0,0 +1,181 @@
+// Package bmp decodes and encodes BGP Monitoring Protocol (BMP) messages, as defined in https://tools.ietf.org/html/rfc7854,
+// with the embedded BGP messages from package bgp.
+package bmp
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/nickchen/packet/fixture/bgp"
+)
+
+// Version of BMP
+const Version = 3
+
+// HeaderSize the size of the common header
+const HeaderSize = 6
+
+// MessageType type of BMP message, https://tools.ietf.org/html/rfc7854#section-4.1
+type MessageType uint8
+
+const (
+	// RouteMonitoringType BGP UPDATE received from the peer
+	RouteMonitoringType MessageType = iota
+	// StatisticsReportType statistics of the peer
+	StatisticsReportType
+	// PeerDownType peer session went down
+	PeerDownType
+	// PeerUpType peer session came up
+	PeerUpType
+	// InitiationType first message of the monitoring session
+	InitiationType
+	// TerminationType last message of the monitoring session
+	TerminationType
+	// RouteMirroringType verbatim duplication of messages from the peer
+	RouteMirroringType
+)
+
+func (t MessageType) String() string {
+	switch t {
+	case RouteMonitoringType:
+		return "Route Monitoring"
+	case StatisticsReportType:
+		return "Statistics Report"
+	case PeerDownType:
+		return "Peer Down Notification"
+	case PeerUpType:
+		return "Peer Up Notification"
+	case InitiationType:
+		return "Initiation"
+	case TerminationType:
+		return "Termination"
+	case RouteMirroringType:
+		return "Route Mirroring"
+	}
+	return fmt.Sprintf("MessageType(%d)", int(t))
+}
+
+// Message BMP message with the common header, https://tools.ietf.org/html/rfc7854#section-4.1.
+// Length is the size of the whole message including the header.
+type Message struct {
+	Version uint8
+	Length  uint32
+	Type    MessageType
+	Body    interface{} `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (m Message) LengthFor(fieldname string) uint64 {
+	if m.Length < HeaderSize {
+		return 0
+	}
+	return uint64(m.Length - HeaderSize)
+}
+
+// InstanceFor interface implementation to provide struct for the body
+func (m Message) InstanceFor(fieldname string) interface{} {
+	switch m.Type {
+	case RouteMonitoringType:
+		return &RouteMonitoring{}
+	case StatisticsReportType:
+		return &StatisticsReport{}
+	case PeerDownType:
+		return &PeerDown{}
+	case PeerUpType:
+		return &PeerUp{}
+	case InitiationType:
+		return &Initiation{}
+	case TerminationType:
+		return &Termination{}
+	case RouteMirroringType:
+		return &RouteMirroring{}
+	}
+	return nil
+}
+
+// InstanceType interface implementation to provide the message type for the body. Route Monitoring being type 0,
+// the Type of other messages has to be set when it's not inferred.
+func (m Message) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	switch body.(type) {
+	case *StatisticsReport:
+		return "Type", uint64(StatisticsReportType)
+	case *PeerDown:
+		return "Type", uint64(PeerDownType)
+	case *PeerUp:
+		return "Type", uint64(PeerUpType)
+	case *Initiation:
+		return "Type", uint64(InitiationType)
+	case *Termination:
+		return "Type", uint64(TerminationType)
+	case *RouteMirroring:
+		return "Type", uint64(RouteMirroringType)
+	}
+	return "", 0
+}
+
+// PeerType type of the monitored peer, https://tools.ietf.org/html/rfc7854#section-4.2
+type PeerType uint8
+
+const (
+	// GlobalInstancePeer peer of the global instance
+	GlobalInstancePeer PeerType = 0
+	// RDInstancePeer peer of a VRF, identified by the peer distinguisher
+	RDInstancePeer PeerType = 1
+	// LocalInstancePeer peer of a local instance
+	LocalInstancePeer PeerType = 2
+)
+
+// PeerFlag flags of the per-peer header
+type PeerFlag uint8
+
+const (
+	// PeerFlagIPv6 the peer address is IPv6
+	PeerFlagIPv6 PeerFlag = 0x80
+	// PeerFlagPostPolicy the routes are post-policy
+	PeerFlagPostPolicy PeerFlag = 0x40
+	// PeerFlagLegacyASPath the BGP messages have 2-octet AS_PATH
+	PeerFlagLegacyASPath PeerFlag = 0x20
+)
+
+// PeerHeader per-peer header, https://tools.ietf.org/html/rfc7854#section-4.2
+type PeerHeader struct {
+	Type          PeerType
+	Flags         PeerFlag
+	Distinguisher bgp.RouteDistinguisher
+	Address       [16]byte
+	AS            bgp.ASN4
+	BGPID         net.IP `packet:"length=4B"`
+	Timestamp     uint32
+	Microseconds  uint32
+}
+
+// IP returns the address of the peer, an IPv4 address being in the last 4 bytes of Address
+func (h PeerHeader) IP() net.IP {
+	if h.Flags&PeerFlagIPv6 != 0 {
+		return net.IP(h.Address[:])
+	}
+	return net.IP(h.Address[12:])
+}
+
+// Time returns the time the message was encapsulated
+func (h PeerHeader) Time() time.Time {
+	return time.Unix(int64(h.Timestamp), int64(h.Microseconds)*int64(time.Microsecond))
+}
+
+// session returns a new session for the BGP messages of the peer, as decided by the legacy AS_PATH flag
+func (h PeerHeader) session() *bgp.Session {
+	return &bgp.Session{FourOctetAS: h.Flags&PeerFlagLegacyASPath == 0}
+}
+
+// RouteMonitoring Route Monitoring message with a BGP UPDATE, https://tools.ietf.org/html/rfc7854#section-4.6
+type RouteMonitoring struct {
+	Peer   PeerHeader
+	Update bgp.Message
+}
+
+// BGPSession implementation of the bgp.SessionProvider interface, the AS_PATH encoding follows the peer flags.
+// The session is new for each call, as it's changed by Observe.
+func (r RouteMonitoring) BGPSession() *bgp.Session {
+	return r.Peer.session()
+}
@@ -0,0 +1,274 @@
+package bmp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nickchen/packet"
+	"github.com/nickchen/packet/fixture/bgp"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkBMP(t *testing.T, want *Message, messageBytes []byte) {
+	m := &Message{}
+	err := packet.Unmarshal(messageBytes, m)
+	assert.NoError(t, err, "failed to decode")
+	assert.Empty(t, cmp.Diff(want, m), "diff found")
+
+	b, err := packet.Marshal(want)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, messageBytes, b, "same data")
+}
+
+// testPeerHeader per-peer header of the IPv4 peer 192.168.0.2 of AS 65001
+var testPeerHeader = []byte{
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0, 0xa8, 0x00, 0x02, 0x00, 0x00, 0xfd, 0xe9, 0xc0, 0xa8,
+	0x00, 0x02, 0x5f, 0x5e, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+var peerHeader = PeerHeader{
+	Address:   [16]byte{12: 0xc0, 13: 0xa8, 14: 0x00, 15: 0x02},
+	AS:        65001,
+	BGPID:     []byte{0xc0, 0xa8, 0x00, 0x02},
+	Timestamp: 1600000000,
+}
+
+func legacyPeerHeader() []byte {
+	b := append([]byte{}, testPeerHeader...)
+	b[1] = byte(PeerFlagLegacyASPath)
+	return b
+}
+
+func bmpMessage(t MessageType, body ...[]byte) []byte {
+	length := HeaderSize
+	for _, b := range body {
+		length += len(b)
+	}
+	m := []byte{Version, byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length), byte(t)}
+	for _, b := range body {
+		m = append(m, b...)
+	}
+	return m
+}
+
+var marker = []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// testRouteMonitoring UPDATE with 4-octet AS_PATH
+var testRouteMonitoring = bmpMessage(RouteMonitoringType, testPeerHeader, marker, []byte{
+	0x00, 0x28, 0x02, 0x00, 0x00, 0x00, 0x0d,
+	0x40, 0x01, 0x01, 0x00,
+	0x40, 0x02, 0x06, 0x02, 0x01, 0x00, 0x00, 0xfd, 0xe9,
+	0x18, 0x0a, 0x01, 0x02,
+})
+
+// testLegacyRouteMonitoring UPDATE with 2-octet AS_PATH
+var testLegacyRouteMonitoring = bmpMessage(RouteMonitoringType, legacyPeerHeader(), marker, []byte{
+	0x00, 0x26, 0x02, 0x00, 0x00, 0x00, 0x0b,
+	0x40, 0x01, 0x01, 0x00,
+	0x40, 0x02, 0x04, 0x02, 0x01, 0xfd, 0xe9,
+	0x18, 0x0a, 0x01, 0x02,
+})
+
+func routeMonitoring(flags PeerFlag, length uint16, attributeLength uint16, asPathLength uint16) *Message {
+	peer := peerHeader
+	peer.Flags = flags
+	return &Message{
+		Version: Version,
+		Length:  uint32(HeaderSize + 42 + length),
+		Type:    RouteMonitoringType,
+		Body: &RouteMonitoring{
+			Peer: peer,
+			Update: bgp.Message{
+				Marker: [16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+				Length: length,
+				Type:   bgp.MessageType(2),
+				Body: &bgp.Update{
+					PathAttributeLength: attributeLength,
+					PathAttributes: []bgp.PathAttribute{
+						bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.Origin, Length: 1, Data: &bgp.OriginAttribute{Origin: bgp.IBGP}},
+						bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.AsPath, Length: asPathLength, Data: &[]bgp.AsPathAttribute{
+							bgp.AsPathAttribute{Type: bgp.AsSequence, Count: 1, List: []bgp.ASN4{65001}},
+						}},
+					},
+					NLRI: []bgp.PrefixSpec{bgp.PrefixSpec{Length: 24, Prefix: []byte{0x0a, 0x01, 0x02}}},
+				},
+			},
+		},
+	}
+}
+
+func TestRouteMonitoring(t *testing.T) {
+	checkBMP(t, routeMonitoring(0, 40, 13, 6), testRouteMonitoring)
+	checkBMP(t, routeMonitoring(PeerFlagLegacyASPath, 38, 11, 4), testLegacyRouteMonitoring)
+	assert.Equal(t, "192.168.0.2", peerHeader.IP().String())
+
+	r := routeMonitoring(0, 40, 13, 6).Body.(*RouteMonitoring)
+	s := r.BGPSession()
+	s.Observe(&bgp.Message{Body: &bgp.Open{Version: 4, AS: 65000}})
+	assert.False(t, s.FourOctetAS, "4-octet AS not negotiated")
+	assert.True(t, r.BGPSession().FourOctetAS, "new session")
+}
+
+// testStatisticsReport rejected prefixes, Adj-RIB-In routes, and Adj-RIB-In routes of IPv4 unicast
+var testStatisticsReport = bmpMessage(StatisticsReportType, testPeerHeader, []byte{
+	0x00, 0x00, 0x00, 0x03,
+	0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0x0a,
+	0x00, 0x07, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x64,
+	0x00, 0x09, 0x00, 0x0b, 0x00, 0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc8,
+})
+
+var statisticsReport = &Message{
+	Version: Version,
+	Length:  87,
+	Type:    StatisticsReportType,
+	Body: &StatisticsReport{
+		Peer:  peerHeader,
+		Count: 3,
+		Stats: []Stat{
+			Stat{Type: RejectedPrefixes, Length: 4, Value: &Counter{Count: 10}},
+			Stat{Type: AdjRIBInRoutes, Length: 8, Value: &Gauge{Gauge: 100}},
+			Stat{Type: AdjRIBInRoutesPerFamily, Length: 11, Value: &FamilyGauge{AFI: bgp.IPv4, SAFI: bgp.Unicast, Gauge: 200}},
+		},
+	},
+}
+
+func TestStatisticsReport(t *testing.T) {
+	checkBMP(t, statisticsReport, testStatisticsReport)
+
+	// lengths patched from the values
+	stats := []Stat{}
+	for _, s := range statisticsReport.Body.(*StatisticsReport).Stats {
+		stats = append(stats, Stat{Type: s.Type, Length: 1, Value: s.Value})
+	}
+	b, err := packet.Marshal(&stats)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testStatisticsReport[len(testStatisticsReport)-35:], b, "same data")
+}
+
+// testPeerUp Peer Up with the OPEN messages of AS 65000 and AS 65001, and a string information
+var testPeerUp = bmpMessage(PeerUpType, testPeerHeader, []byte{
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xc0, 0xa8, 0x00, 0x01,
+	0x00, 0xb3, 0xc3, 0x50,
+}, marker, []byte{
+	0x00, 0x1d, 0x01, 0x04, 0xfd, 0xe8, 0x00, 0xb4, 0xc0, 0xa8, 0x00, 0x01, 0x00,
+}, marker, []byte{
+	0x00, 0x1d, 0x01, 0x04, 0xfd, 0xe9, 0x00, 0xb4, 0xc0, 0xa8, 0x00, 0x02, 0x00,
+	0x00, 0x00, 0x00, 0x02, 0x75, 0x70,
+})
+
+func openMessage(t *testing.T, as uint16, routerID uint32) bgp.Message {
+	m, err := bgp.NewMessage(nil, &bgp.Open{Version: 4, AS: as, Holdtime: 180, RouterID: routerID})
+	assert.NoError(t, err, "failed to create OPEN")
+	return *m
+}
+
+func TestPeerUp(t *testing.T) {
+	want := &Message{
+		Version: Version,
+		Length:  132,
+		Type:    PeerUpType,
+		Body: &PeerUp{
+			Peer:         peerHeader,
+			LocalAddress: [16]byte{12: 0xc0, 13: 0xa8, 14: 0x00, 15: 0x01},
+			LocalPort:    179,
+			RemotePort:   50000,
+			SentOpen:     openMessage(t, 65000, 0xc0a80001),
+			ReceivedOpen: openMessage(t, 65001, 0xc0a80002),
+			Information:  []Information{NewInformation(StringInformation, "up")},
+		},
+	}
+	checkBMP(t, want, testPeerUp)
+	assert.Equal(t, "192.168.0.1", want.Body.(*PeerUp).LocalIP().String())
+}
+
+// testPeerDown Peer Down with the NOTIFICATION of peer de-configured sent
+var testPeerDown = bmpMessage(PeerDownType, testPeerHeader, []byte{0x01}, marker, []byte{
+	0x00, 0x15, 0x03, 0x06, 0x03,
+})
+
+// testPeerDownFSM Peer Down without NOTIFICATION, closed by the FSM event 18
+var testPeerDownFSM = bmpMessage(PeerDownType, testPeerHeader, []byte{0x02, 0x00, 0x12})
+
+func TestPeerDown(t *testing.T) {
+	notification, err := bgp.NewNotification(bgp.Cease, uint8(bgp.PeerDeconfigured), nil)
+	assert.NoError(t, err, "failed to create NOTIFICATION")
+	checkBMP(t, &Message{
+		Version: Version,
+		Length:  70,
+		Type:    PeerDownType,
+		Body:    &PeerDown{Peer: peerHeader, Reason: LocalNotification, Data: notification},
+	}, testPeerDown)
+	checkBMP(t, &Message{
+		Version: Version,
+		Length:  51,
+		Type:    PeerDownType,
+		Body:    &PeerDown{Peer: peerHeader, Reason: LocalNoNotification, Data: &FSMEvent{Event: 18}},
+	}, testPeerDownFSM)
+}
+
+// testInitiation Initiation with sysDescr and sysName
+var testInitiation = bmpMessage(InitiationType, []byte{
+	0x00, 0x01, 0x00, 0x04, 0x74, 0x65, 0x73, 0x74,
+	0x00, 0x02, 0x00, 0x02, 0x72, 0x31,
+})
+
+// testTermination Termination with administratively closed reason and a string
+var testTermination = bmpMessage(TerminationType, []byte{
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x03, 0x62, 0x79, 0x65,
+})
+
+func TestInitiationTermination(t *testing.T) {
+	checkBMP(t, &Message{
+		Version: Version,
+		Length:  20,
+		Type:    InitiationType,
+		Body: &Initiation{Information: []Information{
+			NewInformation(SysDescr, "test"),
+			NewInformation(SysName, "r1"),
+		}},
+	}, testInitiation)
+
+	termination := &Message{
+		Version: Version,
+		Length:  19,
+		Type:    TerminationType,
+		Body: &Termination{Information: []TerminationInformation{
+			TerminationInformation{Type: ReasonTermination, Length: 2, Value: []byte{0x00, 0x00}},
+			TerminationInformation{Type: StringTermination, Length: 3, Value: []byte("bye")},
+		}},
+	}
+	checkBMP(t, termination, testTermination)
+	information := termination.Body.(*Termination).Information
+	assert.Equal(t, "Session administratively closed", information[0].String())
+	assert.Equal(t, "bye", information[1].String())
+}
+
+// testRouteMirroring KEEPALIVE of the peer, and messages lost
+var testRouteMirroring = bmpMessage(RouteMirroringType, testPeerHeader, []byte{0x00, 0x00, 0x00, 0x13}, marker, []byte{
+	0x00, 0x13, 0x04,
+	0x00, 0x01, 0x00, 0x02, 0x00, 0x01,
+})
+
+func TestRouteMirroring(t *testing.T) {
+	mirroring := &Message{
+		Version: Version,
+		Length:  77,
+		Type:    RouteMirroringType,
+		Body: &RouteMirroring{
+			Peer: peerHeader,
+			TLVs: []Mirroring{
+				Mirroring{Type: MirroredMessage, Length: 19, Value: &bgp.Message{
+					Marker: [16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+					Length: 19,
+					Type:   bgp.MessageType(4),
+					Body:   &bgp.Keepalive{},
+				}},
+				Mirroring{Type: MirroredInformation, Length: 2, Value: &MirroringInformation{Code: MessagesLost}},
+			},
+		},
+	}
+	checkBMP(t, mirroring, testRouteMirroring)
+	assert.Equal(t, "Messages Lost", MessagesLost.String())
+}
@@ -0,0 +1,110 @@
+package bmp
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// InformationType type of the information TLV, https://tools.ietf.org/html/rfc7854#section-4.4
+type InformationType uint16
+
+const (
+	// StringInformation free-form UTF-8 string
+	StringInformation InformationType = 0
+	// SysDescr ASCII string of the system description
+	SysDescr InformationType = 1
+	// SysName ASCII string of the system name
+	SysName InformationType = 2
+)
+
+// Information information TLV of Initiation and Peer Up Notification
+type Information struct {
+	Type   InformationType
+	Length uint16
+	Value  string `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (i Information) LengthFor(fieldname string) uint64 {
+	return uint64(i.Length)
+}
+
+// NewInformation returns the information TLV with the Length set
+func NewInformation(t InformationType, value string) Information {
+	return Information{Type: t, Length: uint16(len(value)), Value: value}
+}
+
+// Initiation Initiation message, https://tools.ietf.org/html/rfc7854#section-4.3
+type Initiation struct {
+	Information []Information
+}
+
+// TerminationInformationType type of the termination TLV, https://tools.ietf.org/html/rfc7854#section-4.5
+type TerminationInformationType uint16
+
+const (
+	// StringTermination free-form UTF-8 string
+	StringTermination TerminationInformationType = 0
+	// ReasonTermination 2-byte reason code
+	ReasonTermination TerminationInformationType = 1
+)
+
+// TerminationReason reason of the termination
+type TerminationReason uint16
+
+// Termination reasons
+const (
+	AdministrativelyClosed TerminationReason = iota
+	UnspecifiedReason
+	OutOfResources
+	RedundantConnection
+	PermanentlyClosed
+)
+
+func (r TerminationReason) String() string {
+	switch r {
+	case AdministrativelyClosed:
+		return "Session administratively closed"
+	case UnspecifiedReason:
+		return "Unspecified reason"
+	case OutOfResources:
+		return "Out of resources"
+	case RedundantConnection:
+		return "Redundant connection"
+	case PermanentlyClosed:
+		return "Session permanently administratively closed"
+	}
+	return fmt.Sprintf("TerminationReason(%d)", int(r))
+}
+
+// TerminationInformation termination TLV, with either a string or a reason as Value
+type TerminationInformation struct {
+	Type   TerminationInformationType
+	Length uint16
+	Value  []byte `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (t TerminationInformation) LengthFor(fieldname string) uint64 {
+	return uint64(t.Length)
+}
+
+// Reason returns the reason code of the reason TLV
+func (t TerminationInformation) Reason() (TerminationReason, bool) {
+	if t.Type != ReasonTermination || len(t.Value) != 2 {
+		return 0, false
+	}
+	return TerminationReason(binary.BigEndian.Uint16(t.Value)), true
+}
+
+func (t TerminationInformation) String() string {
+	if r, ok := t.Reason(); ok {
+		return r.String()
+	}
+	return string(t.Value)
+}
+
+// Termination Termination message, https://tools.ietf.org/html/rfc7854#section-4.5
+type Termination struct {
+	Information []TerminationInformation
+}
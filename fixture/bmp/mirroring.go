@@ -0,0 +1,88 @@
+package bmp
+
+import (
+	"fmt"
+
+	"github.com/nickchen/packet/fixture/bgp"
+)
+
+// MirroringType type of the Route Mirroring TLV, https://tools.ietf.org/html/rfc7854#section-4.7
+type MirroringType uint16
+
+const (
+	// MirroredMessage BGP message as received from the peer
+	MirroredMessage MirroringType = 0
+	// MirroredInformation MirroringInformation about the mirrored messages
+	MirroredInformation MirroringType = 1
+)
+
+// MirroringCode code of the information TLV
+type MirroringCode uint16
+
+const (
+	// ErroredPDU the mirrored message is an errored PDU
+	ErroredPDU MirroringCode = 0
+	// MessagesLost one or more messages were lost
+	MessagesLost MirroringCode = 1
+)
+
+func (c MirroringCode) String() string {
+	switch c {
+	case ErroredPDU:
+		return "Errored PDU"
+	case MessagesLost:
+		return "Messages Lost"
+	}
+	return fmt.Sprintf("MirroringCode(%d)", int(c))
+}
+
+// MirroringInformation value of the information TLV
+type MirroringInformation struct {
+	Code MirroringCode
+}
+
+// Mirroring TLV of Route Mirroring, with a bgp.Message or MirroringInformation as Value
+type Mirroring struct {
+	Type   MirroringType
+	Length uint16
+	Value  interface{} `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (m Mirroring) LengthFor(fieldname string) uint64 {
+	return uint64(m.Length)
+}
+
+// InstanceFor interface implementation to provide struct for the value
+func (m Mirroring) InstanceFor(fieldname string) interface{} {
+	switch m.Type {
+	case MirroredMessage:
+		return &bgp.Message{}
+	case MirroredInformation:
+		return &MirroringInformation{}
+	}
+	return nil
+}
+
+// InstanceType interface implementation to provide the TLV type for the value
+func (m Mirroring) InstanceType(fieldname string, value interface{}) (string, uint64) {
+	switch value.(type) {
+	case *bgp.Message:
+		return "Type", uint64(MirroredMessage)
+	case *MirroringInformation:
+		return "Type", uint64(MirroredInformation)
+	}
+	return "", 0
+}
+
+// RouteMirroring Route Mirroring message with the messages of the peer verbatim, https://tools.ietf.org/html/rfc7854#section-4.7
+type RouteMirroring struct {
+	Peer PeerHeader
+	TLVs []Mirroring
+}
+
+// BGPSession implementation of the bgp.SessionProvider interface, the AS_PATH encoding follows the peer flags.
+// The session is new for each call, as it's changed by Observe.
+func (r RouteMirroring) BGPSession() *bgp.Session {
+	return r.Peer.session()
+}
@@ -0,0 +1,65 @@
+package bmp
+
+import (
+	"net"
+
+	"github.com/nickchen/packet/fixture/bgp"
+)
+
+// PeerUp Peer Up Notification, with the OPEN messages exchanged, https://tools.ietf.org/html/rfc7854#section-4.10
+type PeerUp struct {
+	Peer         PeerHeader
+	LocalAddress [16]byte
+	LocalPort    uint16
+	RemotePort   uint16
+	SentOpen     bgp.Message
+	ReceivedOpen bgp.Message
+	Information  []Information
+}
+
+// LocalIP returns the local address of the session, an IPv4 address being in the last 4 bytes of LocalAddress
+func (p PeerUp) LocalIP() net.IP {
+	if p.Peer.Flags&PeerFlagIPv6 != 0 {
+		return net.IP(p.LocalAddress[:])
+	}
+	return net.IP(p.LocalAddress[12:])
+}
+
+// PeerDownReason reason of the Peer Down Notification
+type PeerDownReason uint8
+
+const (
+	// LocalNotification the local system closed the session with the NOTIFICATION in Data
+	LocalNotification PeerDownReason = 1 + iota
+	// LocalNoNotification the local system closed the session without NOTIFICATION, with the FSM event in Data
+	LocalNoNotification
+	// RemoteNotification the remote system closed the session with the NOTIFICATION in Data
+	RemoteNotification
+	// RemoteNoNotification the remote system closed the session without NOTIFICATION
+	RemoteNoNotification
+	// PeerDeconfigured the peer has been de-configured
+	PeerDeconfigured
+)
+
+// PeerDown Peer Down Notification, https://tools.ietf.org/html/rfc7854#section-4.9
+type PeerDown struct {
+	Peer   PeerHeader
+	Reason PeerDownReason
+	Data   interface{}
+}
+
+// FSMEvent finite state machine event that closed the session, https://tools.ietf.org/html/rfc4271#section-8.1
+type FSMEvent struct {
+	Event uint16
+}
+
+// InstanceFor interface implementation to provide struct for the data
+func (p PeerDown) InstanceFor(fieldname string) interface{} {
+	switch p.Reason {
+	case LocalNotification, RemoteNotification:
+		return &bgp.Message{}
+	case LocalNoNotification:
+		return &FSMEvent{}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package bmp
+
+import (
+	"fmt"
+
+	"github.com/nickchen/packet"
+	"github.com/nickchen/packet/fixture/bgp"
+)
+
+// StatisticsReport Stats Report message, https://tools.ietf.org/html/rfc7854#section-4.8
+type StatisticsReport struct {
+	Peer  PeerHeader
+	Count uint32
+	Stats []Stat `packet:"countfrom=Count"`
+}
+
+// StatType type of the statistics
+type StatType uint16
+
+// Statistics types
+const (
+	RejectedPrefixes StatType = iota
+	DuplicatePrefixAdvertisements
+	DuplicateWithdraws
+	InvalidatedClusterListLoop
+	InvalidatedASPathLoop
+	InvalidatedOriginatorID
+	InvalidatedASConfedLoop
+	AdjRIBInRoutes
+	LocRIBRoutes
+	AdjRIBInRoutesPerFamily
+	LocRIBRoutesPerFamily
+	UpdatesTreatAsWithdraw
+	PrefixesTreatAsWithdraw
+	DuplicateUpdates
+)
+
+func (t StatType) String() string {
+	switch t {
+	case RejectedPrefixes:
+		return "Rejected Prefixes"
+	case DuplicatePrefixAdvertisements:
+		return "Duplicate Prefix Advertisements"
+	case DuplicateWithdraws:
+		return "Duplicate Withdraws"
+	case InvalidatedClusterListLoop:
+		return "Invalidated CLUSTER_LIST Loop"
+	case InvalidatedASPathLoop:
+		return "Invalidated AS_PATH Loop"
+	case InvalidatedOriginatorID:
+		return "Invalidated ORIGINATOR_ID"
+	case InvalidatedASConfedLoop:
+		return "Invalidated AS_CONFED Loop"
+	case AdjRIBInRoutes:
+		return "Adj-RIBs-In Routes"
+	case LocRIBRoutes:
+		return "Loc-RIB Routes"
+	case AdjRIBInRoutesPerFamily:
+		return "Per-AFI/SAFI Adj-RIB-In Routes"
+	case LocRIBRoutesPerFamily:
+		return "Per-AFI/SAFI Loc-RIB Routes"
+	case UpdatesTreatAsWithdraw:
+		return "Updates Treated as Withdraw"
+	case PrefixesTreatAsWithdraw:
+		return "Prefixes Treated as Withdraw"
+	case DuplicateUpdates:
+		return "Duplicate Updates"
+	}
+	return fmt.Sprintf("StatType(%d)", int(t))
+}
+
+// Stat statistics TLV, the value is a 32-bit counter, a 64-bit gauge, or a 64-bit gauge per address family
+type Stat struct {
+	Type   StatType    `packet:"tlv=type"`
+	Length uint16      `packet:"tlv=length"`
+	Value  interface{} `packet:"tlv=value"`
+}
+
+// Counter 32-bit counter statistics
+type Counter struct {
+	Count uint32
+}
+
+// Gauge 64-bit gauge statistics
+type Gauge struct {
+	Gauge uint64
+}
+
+// FamilyGauge 64-bit gauge statistics of the address family
+type FamilyGauge struct {
+	AFI   bgp.AFI
+	SAFI  bgp.SAFI
+	Gauge uint64
+}
+
+// InstanceFor interface implementation to provide struct for the value
+func (s Stat) InstanceFor(fieldname string) interface{} {
+	switch s.Type {
+	case AdjRIBInRoutes, LocRIBRoutes:
+		return &Gauge{}
+	case AdjRIBInRoutesPerFamily, LocRIBRoutesPerFamily:
+		return &FamilyGauge{}
+	}
+	if s.Type <= DuplicateUpdates {
+		return &Counter{}
+	}
+	return nil
+}
+
+var statSpec = &packet.TLVSpec{}
+
+// TLV returns the spec of statistics, the value structs being provided by InstanceFor as most types share Counter
+func (s Stat) TLV() *packet.TLVSpec {
+	return statSpec
+}
@@ -0,0 +1,174 @@
+package fixture
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/nickchen/packet"
+)
+
+// InternetChecksum returns the one's complement of the one's complement sum of the data, as per https://tools.ietf.org/html/rfc1071,
+// with initial as the partial sum of a pseudo header
+func InternetChecksum(data []byte, initial uint32) uint16 {
+	sum := initial
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(data[i:]))
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return ^uint16(sum)
+}
+
+// pseudoHeaderSum returns the partial sum of the pseudo header of TCP, UDP and ICMPv6
+func pseudoHeaderSum(source, dest net.IP, protocol IPProtocol, length int) uint32 {
+	sum := uint32(0)
+	for _, ip := range []net.IP{source, dest} {
+		for i := 0; i+1 < len(ip); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(ip[i:]))
+		}
+	}
+	return sum + uint32(protocol) + uint32(length&0xffff) + uint32(length>>16)
+}
+
+// transportChecksum returns the checksum field of the transport protocol, and whether the pseudo header is included
+func transportChecksum(body interface{}) (*Checksum, bool) {
+	switch t := body.(type) {
+	case *TCP:
+		return &t.Checksum, true
+	case *UDP:
+		return &t.Checksum, true
+	case *ICMPv6:
+		return &t.Checksum, true
+	case *ICMPv4:
+		return &t.Checksum, false
+	}
+	return nil, false
+}
+
+// computeTransportChecksum returns the checksum of the transport protocol, with the checksum field as zero
+func computeTransportChecksum(source, dest net.IP, protocol IPProtocol, body interface{}) (Checksum, error) {
+	c, pseudo := transportChecksum(body)
+	saved := *c
+	*c = 0
+	b, err := packet.Marshal(body)
+	*c = saved
+	if err != nil {
+		return 0, err
+	}
+	initial := uint32(0)
+	if pseudo {
+		initial = pseudoHeaderSum(source, dest, protocol, len(b))
+	}
+	checksum := Checksum(InternetChecksum(b, initial))
+	if _, ok := body.(*UDP); ok && checksum == 0 {
+		checksum = 0xffff
+	}
+	return checksum, nil
+}
+
+// upperLayer returns the upper layer of IPv6 after the extension headers, along with its protocol and the final destination
+func (ip *IPv6) upperLayer() (IPProtocol, interface{}, net.IP) {
+	protocol, body, dest := ip.NextHeader, ip.Body, ip.Dest
+	for {
+		switch h := body.(type) {
+		case *HopByHop:
+			protocol, body = h.NextHeader, h.Body
+		case *DestinationOptions:
+			protocol, body = h.NextHeader, h.Body
+		case *Routing:
+			if srh, ok := h.Data.(*SegmentRouting); ok && len(srh.Segments) > 0 {
+				dest = net.IP(srh.Segments[0][:])
+			}
+			protocol, body = h.NextHeader, h.Body
+		case *Fragment:
+			protocol, body = h.NextHeader, h.Body
+		case *AH:
+			protocol, body = h.NextHeader, h.Body
+		default:
+			return protocol, body, dest
+		}
+	}
+}
+
+// UpdateChecksums computes the header checksum, and the checksum of TCP, UDP or ICMP in Body
+func (ip *IPv4) UpdateChecksums() error {
+	if c, _ := transportChecksum(ip.Body); c != nil {
+		checksum, err := computeTransportChecksum(ip.Source.To4(), ip.Dest.To4(), ip.Protocol, ip.Body)
+		if err != nil {
+			return err
+		}
+		*c = checksum
+	}
+	checksum, err := ip.headerChecksum()
+	if err != nil {
+		return err
+	}
+	ip.Checksum = checksum
+	return nil
+}
+
+// VerifyChecksums checks the header checksum, and the checksum of TCP, UDP or ICMP in Body
+func (ip *IPv4) VerifyChecksums() (bool, error) {
+	checksum, err := ip.headerChecksum()
+	if err != nil || checksum != ip.Checksum {
+		return false, err
+	}
+	c, _ := transportChecksum(ip.Body)
+	if c == nil {
+		return true, nil
+	}
+	if _, ok := ip.Body.(*UDP); ok && *c == 0 {
+		return true, nil
+	}
+	checksum, err = computeTransportChecksum(ip.Source.To4(), ip.Dest.To4(), ip.Protocol, ip.Body)
+	return err == nil && checksum == *c, err
+}
+
+func (ip *IPv4) headerChecksum() (Checksum, error) {
+	saved := ip.Checksum
+	ip.Checksum = 0
+	b, err := packet.Marshal(ip)
+	ip.Checksum = saved
+	if err != nil {
+		return 0, err
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	// IHL is patched by Marshal
+	length := 4 * int(b[0]&0x0f)
+	if length > len(b) {
+		length = len(b)
+	}
+	return Checksum(InternetChecksum(b[:length], 0)), nil
+}
+
+// UpdateChecksums computes the checksum of TCP, UDP or ICMPv6 after the extension headers
+func (ip *IPv6) UpdateChecksums() error {
+	protocol, body, dest := ip.upperLayer()
+	c, _ := transportChecksum(body)
+	if c == nil {
+		return nil
+	}
+	checksum, err := computeTransportChecksum(ip.Source, dest, protocol, body)
+	if err != nil {
+		return err
+	}
+	*c = checksum
+	return nil
+}
+
+// VerifyChecksums checks the checksum of TCP, UDP or ICMPv6 after the extension headers
+func (ip *IPv6) VerifyChecksums() (bool, error) {
+	protocol, body, dest := ip.upperLayer()
+	c, _ := transportChecksum(body)
+	if c == nil {
+		return true, nil
+	}
+	checksum, err := computeTransportChecksum(ip.Source, dest, protocol, body)
+	return err == nil && checksum == *c, err
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/nickchen/packet"
 	"github.com/nickchen/packet/fixture/bgp"
 )
 
@@ -21,8 +22,25 @@ type EtherType uint16
 
 const (
 	_IPv4 EtherType = 0x0800
+	_ARP  EtherType = 0x0806
+	// _TEB transparent ethernet bridging, for ethernet frames in tunnels
+	_TEB  EtherType = 0x6558
 	_Vlan EtherType = 0x8100
 	_IPv6 EtherType = 0x86DD
+	_MPLS EtherType = 0x8847
+	// _MPLSMulticast multicast MPLS, https://tools.ietf.org/html/rfc5332
+	_MPLSMulticast EtherType = 0x8848
+	_QinQ          EtherType = 0x88A8
+	_LLDP          EtherType = 0x88CC
+	// _QinQ9100 pre-standard outer tag
+	_QinQ9100 EtherType = 0x9100
+)
+
+// TPIDs of VLAN tags, for the outer tag of 802.1ad (QinQ) and the pre-standard outer tag
+const (
+	TPID8021Q  = _Vlan
+	TPID8021AD = _QinQ
+	TPID9100   = _QinQ9100
 )
 
 func (t EtherType) String() string {
@@ -31,8 +49,22 @@ func (t EtherType) String() string {
 		return "IPv4"
 	case _Vlan:
 		return "VLAN"
+	case _ARP:
+		return "ARP"
+	case _TEB:
+		return "TEB"
 	case _IPv6:
 		return "IPv6"
+	case _MPLS:
+		return "MPLS"
+	case _MPLSMulticast:
+		return "MPLS(multicast)"
+	case _LLDP:
+		return "LLDP"
+	case _QinQ:
+		return "QinQ"
+	case _QinQ9100:
+		return "QinQ(0x9100)"
 	}
 	return fmt.Sprintf("0x%x", int(t))
 }
@@ -45,7 +77,7 @@ type EthernetII struct {
 	Body   interface{}
 }
 
-// VLAN virtual-LAN
+// VLAN virtual-LAN, the tags being stacked for QinQ with Type of the outer tag being 0x88A8 or 0x9100
 type VLAN struct {
 	Priority uint8 `packet:"length=3b"`
 	DEI      bool
@@ -58,36 +90,109 @@ func bodyStructEtherType(t EtherType) interface{} {
 	switch t {
 	case _IPv4:
 		return &IPv4{}
-	case _Vlan:
+	case _ARP:
+		return &ARP{}
+	case _TEB:
+		return &EthernetII{}
+	case _Vlan, _QinQ, _QinQ9100:
 		return &VLAN{}
+	case _IPv6:
+		return &IPv6{}
+	case _MPLS, _MPLSMulticast:
+		return &MPLS{}
+	case _LLDP:
+		return &LLDP{}
 	}
 	return nil
 }
 
+func etherTypeBodyStruct(body interface{}) EtherType {
+	switch body.(type) {
+	case *IPv4:
+		return _IPv4
+	case *VLAN:
+		return _Vlan
+	case *ARP:
+		return _ARP
+	case *EthernetII:
+		return _TEB
+	case *IPv6:
+		return _IPv6
+	case *MPLS:
+		return _MPLS
+	case *LLDP:
+		return _LLDP
+	}
+	return 0
+}
+
 // InstanceFor return the Body struct pointer for conversion
 func (e EthernetII) InstanceFor(fieldname string) interface{} {
 	return bodyStructEtherType(e.Type)
 }
 
+// InstanceType returns the Type for the Body struct pointer
+func (e EthernetII) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	if t := etherTypeBodyStruct(body); t != 0 {
+		return "Type", uint64(t)
+	}
+	return "", 0
+}
+
 // InstanceFor return the Body struct pointer for conversion
 func (v VLAN) InstanceFor(fieldname string) interface{} {
 	return bodyStructEtherType(v.Type)
 }
 
+// InstanceType returns the Type for the Body struct pointer
+func (v VLAN) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	if t := etherTypeBodyStruct(body); t != 0 {
+		return "Type", uint64(t)
+	}
+	return "", 0
+}
+
 // IPProtocol protocol type
 type IPProtocol uint8
 
 const (
-	_TCP IPProtocol = 6
-	_UDP IPProtocol = 17
+	_HopByHop IPProtocol = 0
+	_ICMP     IPProtocol = 1
+	_TCP      IPProtocol = 6
+	_UDP      IPProtocol = 17
+	_Routing  IPProtocol = 43
+	_Fragment IPProtocol = 44
+	_GRE      IPProtocol = 47
+	_AH       IPProtocol = 51
+	_ICMPv6   IPProtocol = 58
+	_NoNext   IPProtocol = 59
+	_DestOpts IPProtocol = 60
 )
 
 func (p IPProtocol) String() string {
 	switch p {
+	case _HopByHop:
+		return "HOPOPT"
+	case _ICMP:
+		return "ICMP"
 	case _TCP:
 		return "TCP"
 	case _UDP:
 		return "UDP"
+	case _Routing:
+		return "IPv6-Route"
+	case _Fragment:
+		return "IPv6-Frag"
+	case _GRE:
+		return "GRE"
+	case _AH:
+		return "AH"
+	case _ICMPv6:
+		return "IPv6-ICMP"
+	case _NoNext:
+		return "IPv6-NoNxt"
+	case _DestOpts:
+		return "IPv6-Opts"
 	}
 	return fmt.Sprintf("Protocol(unknown:%d)", int(p))
 }
@@ -122,10 +227,10 @@ func (f IPv4Flag) String() string {
 	return strings.Join(s, "|")
 }
 
-// IPv4 packet
+// IPv4 packet, IHL is filled in from the Options by Marshal, which are padded to 4 bytes
 type IPv4 struct {
 	Version        uint8 `packet:"length=4b"`
-	IHL            uint8 `packet:"length=4b"`
+	IHL            uint8 `packet:"length=4b,tlv=length"`
 	DSCP           uint8 `packet:"length=6b"`
 	ECN            uint8 `packet:"length=2b"`
 	Length         uint16
@@ -135,30 +240,113 @@ type IPv4 struct {
 	TTL            uint8
 	Protocol       IPProtocol
 	Checksum       Checksum
-	Source         net.IP `packet:"length=4B"`
-	Dest           net.IP `packet:"length=4B"`
-	Options        []byte `packet:"lengthfor"`
+	Source         net.IP       `packet:"length=4B"`
+	Dest           net.IP       `packet:"length=4B"`
+	Options        []IPv4Option `packet:"tlv=value"`
 	Body           interface{}
 }
 
-// InstanceFor returns the Body struct pointer for conversion
-func (ip IPv4) InstanceFor(fieldname string) interface{} {
-	switch ip.Protocol {
+var ipv4Spec = &packet.TLVSpec{
+	Inclusive: true,
+	Unit:      4,
+}
+
+// TLV returns the spec of the header, IHL being the length in 4 bytes unit including the Options
+func (ip IPv4) TLV() *packet.TLVSpec {
+	return ipv4Spec
+}
+
+// bodyStructIPProtocol returns the struct for the next header of IPv6, see ipv4BodyStruct for IPv4
+func bodyStructIPProtocol(p IPProtocol) interface{} {
+	switch p {
+	case _HopByHop:
+		return &HopByHop{}
+	case _ICMP:
+		return &ICMPv4{}
 	case _TCP:
 		return &TCP{}
 	case _UDP:
+		return &UDP{}
+	case _Routing:
+		return &Routing{}
+	case _Fragment:
+		return &Fragment{}
+	case _GRE:
+		return &GRE{}
+	case _AH:
+		return &AH{}
+	case _ICMPv6:
+		return &ICMPv6{}
+	case _DestOpts:
+		return &DestinationOptions{}
 	}
-	// panic(fmt.Errorf("unhandle protocol (%s)", ip.Protocol))
 	return nil
 }
 
-// LengthFor returns the length in bytes for the provided field
-func (ip IPv4) LengthFor(fieldname string) uint64 {
-	switch fieldname {
-	case "Options":
-		return uint64((32*ip.IHL - 32*5) / 8)
+func ipProtocolBodyStruct(body interface{}) (IPProtocol, bool) {
+	switch body.(type) {
+	case *HopByHop:
+		return _HopByHop, true
+	case *ICMPv4:
+		return _ICMP, true
+	case *TCP:
+		return _TCP, true
+	case *UDP:
+		return _UDP, true
+	case *Routing:
+		return _Routing, true
+	case *Fragment:
+		return _Fragment, true
+	case *GRE:
+		return _GRE, true
+	case *AH:
+		return _AH, true
+	case *ICMPv6:
+		return _ICMPv6, true
+	case *DestinationOptions:
+		return _DestOpts, true
 	}
-	return 0
+	return 0, false
+}
+
+// ipv6ExtensionHeader returns true for the IPv6 extension headers, which are not carried by IPv4
+func ipv6ExtensionHeader(p IPProtocol) bool {
+	switch p {
+	case _HopByHop, _Routing, _Fragment, _DestOpts:
+		return true
+	}
+	return false
+}
+
+// ipv4BodyStruct returns the struct for the protocol of IPv4, the IPv6 extension headers being left as Raw
+func ipv4BodyStruct(p IPProtocol) interface{} {
+	if ipv6ExtensionHeader(p) {
+		return nil
+	}
+	return bodyStructIPProtocol(p)
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (ip IPv4) InstanceFor(fieldname string) interface{} {
+	return ipv4BodyStruct(ip.Protocol)
+}
+
+// InstanceForContext returns the Body struct pointer for conversion, the datagram quoted by ICMPv4Error being kept
+// as Raw, since it's cut after the first 8 bytes of the transport
+func (ip IPv4) InstanceForContext(ctx *packet.Context, fieldname string) interface{} {
+	switch ctx.Parent(1).(type) {
+	case *ICMPv4Error, ICMPv4Error:
+		return nil
+	}
+	return ip.InstanceFor(fieldname)
+}
+
+// InstanceType returns the Protocol for the Body struct pointer
+func (ip IPv4) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	if p, ok := ipProtocolBodyStruct(body); ok && !ipv6ExtensionHeader(p) {
+		return "Protocol", uint64(p)
+	}
+	return "", 0
 }
 
 // Port alias for uint16, so we can use it with constants
@@ -166,7 +354,9 @@ type Port uint16
 
 // Well know ports
 const (
-	_BGP Port = 179
+	_BGP    Port = 179
+	_VXLAN  Port = 4789
+	_Geneve Port = 6081
 )
 
 // TCPFlag is 9 bits
@@ -227,7 +417,7 @@ type TCP struct {
 	WindowSize    uint16
 	Checksum      Checksum
 	UrgentPointer uint16
-	Options       []byte `packet:"lengthfor"`
+	Options       []TCPOption `packet:"lengthfor"`
 	Body          interface{}
 }
 
@@ -240,11 +430,13 @@ func (tcp TCP) InstanceFor(fieldname string) interface{} {
 	return nil
 }
 
-// LengthFor
+// LengthFor returns the length in bytes for the provided field, DataOffset being in 4 bytes unit including the 20 bytes header
 func (tcp TCP) LengthFor(fieldname string) uint64 {
 	switch fieldname {
 	case "Options":
-		return uint64((32*tcp.DataOffset - 32*5) / 8)
+		if tcp.DataOffset > 5 {
+			return 4*uint64(tcp.DataOffset) - 20
+		}
 	}
 	return 0
 }
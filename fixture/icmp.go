@@ -0,0 +1,262 @@
+package fixture
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/nickchen/packet"
+)
+
+// ICMPv4Type type of ICMP message, https://tools.ietf.org/html/rfc792
+type ICMPv4Type uint8
+
+const (
+	EchoReply              ICMPv4Type = 0
+	DestinationUnreachable ICMPv4Type = 3
+	Echo                   ICMPv4Type = 8
+	TimeExceeded           ICMPv4Type = 11
+)
+
+func (t ICMPv4Type) String() string {
+	switch t {
+	case EchoReply:
+		return "Echo Reply"
+	case DestinationUnreachable:
+		return "Destination Unreachable"
+	case Echo:
+		return "Echo"
+	case TimeExceeded:
+		return "Time Exceeded"
+	}
+	return fmt.Sprintf("ICMPv4Type(%d)", int(t))
+}
+
+// ICMPv4 message
+type ICMPv4 struct {
+	Type     ICMPv4Type
+	Code     uint8
+	Checksum Checksum
+	Body     interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (icmp ICMPv4) InstanceFor(fieldname string) interface{} {
+	switch icmp.Type {
+	case EchoReply, Echo:
+		return &ICMPEcho{}
+	case DestinationUnreachable, TimeExceeded:
+		return &ICMPv4Error{}
+	}
+	return nil
+}
+
+// ICMPEcho echo request and reply of ICMPv4 and ICMPv6
+type ICMPEcho struct {
+	ID       uint16
+	Sequence uint16
+	Data     []byte
+}
+
+// ICMPv4Error destination unreachable and time exceeded, with the IP header and the first 8 bytes of the original datagram,
+// which are kept as the Raw body of Original. NextHopMTU is only for fragmentation needed, https://tools.ietf.org/html/rfc1191#section-4
+type ICMPv4Error struct {
+	Unused     uint16
+	NextHopMTU uint16
+	Original   IPv4
+}
+
+// ICMPv6Type type of ICMPv6 message, https://tools.ietf.org/html/rfc4443 and https://tools.ietf.org/html/rfc4861
+type ICMPv6Type uint8
+
+const (
+	ICMPv6DestinationUnreachable ICMPv6Type = 1
+	PacketTooBig                 ICMPv6Type = 2
+	ICMPv6TimeExceeded           ICMPv6Type = 3
+	EchoRequest                  ICMPv6Type = 128
+	ICMPv6EchoReply              ICMPv6Type = 129
+	RouterSolicitation           ICMPv6Type = 133
+	RouterAdvertisement          ICMPv6Type = 134
+	NeighborSolicitation         ICMPv6Type = 135
+	NeighborAdvertisement        ICMPv6Type = 136
+	Redirect                     ICMPv6Type = 137
+)
+
+func (t ICMPv6Type) String() string {
+	switch t {
+	case ICMPv6DestinationUnreachable:
+		return "Destination Unreachable"
+	case PacketTooBig:
+		return "Packet Too Big"
+	case ICMPv6TimeExceeded:
+		return "Time Exceeded"
+	case EchoRequest:
+		return "Echo Request"
+	case ICMPv6EchoReply:
+		return "Echo Reply"
+	case RouterSolicitation:
+		return "Router Solicitation"
+	case RouterAdvertisement:
+		return "Router Advertisement"
+	case NeighborSolicitation:
+		return "Neighbor Solicitation"
+	case NeighborAdvertisement:
+		return "Neighbor Advertisement"
+	case Redirect:
+		return "Redirect"
+	}
+	return fmt.Sprintf("ICMPv6Type(%d)", int(t))
+}
+
+// ICMPv6 message
+type ICMPv6 struct {
+	Type     ICMPv6Type
+	Code     uint8
+	Checksum Checksum
+	Body     interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (icmp ICMPv6) InstanceFor(fieldname string) interface{} {
+	switch icmp.Type {
+	case ICMPv6DestinationUnreachable, PacketTooBig, ICMPv6TimeExceeded:
+		return &ICMPv6Error{}
+	case EchoRequest, ICMPv6EchoReply:
+		return &ICMPEcho{}
+	case RouterSolicitation:
+		return &RouterSolicitationMessage{}
+	case RouterAdvertisement:
+		return &RouterAdvertisementMessage{}
+	case NeighborSolicitation:
+		return &NeighborSolicitationMessage{}
+	case NeighborAdvertisement:
+		return &NeighborAdvertisementMessage{}
+	case Redirect:
+		return &RedirectMessage{}
+	}
+	return nil
+}
+
+// InstanceType returns the Type for the Body struct pointer, only the NDP messages are inferred
+func (icmp ICMPv6) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	switch body.(type) {
+	case *RouterSolicitationMessage:
+		return "Type", uint64(RouterSolicitation)
+	case *RouterAdvertisementMessage:
+		return "Type", uint64(RouterAdvertisement)
+	case *NeighborSolicitationMessage:
+		return "Type", uint64(NeighborSolicitation)
+	case *NeighborAdvertisementMessage:
+		return "Type", uint64(NeighborAdvertisement)
+	case *RedirectMessage:
+		return "Type", uint64(Redirect)
+	}
+	return "", 0
+}
+
+// ICMPv6Error destination unreachable, packet too big and time exceeded, with as much of the original packet as possible.
+// Parameter is the MTU for packet too big, and unused otherwise.
+type ICMPv6Error struct {
+	Parameter uint32
+	Original  IPv6
+}
+
+// RouterSolicitationMessage router solicitation, https://tools.ietf.org/html/rfc4861#section-4.1
+type RouterSolicitationMessage struct {
+	Reserved uint32
+	Options  []NDPOption
+}
+
+// RouterAdvertisementMessage router advertisement, https://tools.ietf.org/html/rfc4861#section-4.2
+type RouterAdvertisementMessage struct {
+	CurHopLimit    uint8
+	Managed        bool
+	Other          bool
+	Reserved       uint8 `packet:"length=6b"`
+	RouterLifetime uint16
+	ReachableTime  uint32
+	RetransTimer   uint32
+	Options        []NDPOption
+}
+
+// NeighborSolicitationMessage neighbor solicitation, https://tools.ietf.org/html/rfc4861#section-4.3
+type NeighborSolicitationMessage struct {
+	Reserved uint32
+	Target   net.IP `packet:"length=16B"`
+	Options  []NDPOption
+}
+
+// NeighborAdvertisementMessage neighbor advertisement, https://tools.ietf.org/html/rfc4861#section-4.4
+type NeighborAdvertisementMessage struct {
+	Router    bool
+	Solicited bool
+	Override  bool
+	Reserved  uint32 `packet:"length=29b"`
+	Target    net.IP `packet:"length=16B"`
+	Options   []NDPOption
+}
+
+// RedirectMessage redirect, https://tools.ietf.org/html/rfc4861#section-4.5
+type RedirectMessage struct {
+	Reserved uint32
+	Target   net.IP `packet:"length=16B"`
+	Dest     net.IP `packet:"length=16B"`
+	Options  []NDPOption
+}
+
+// NDPOptionType type of NDP option, https://tools.ietf.org/html/rfc4861#section-4.6
+type NDPOptionType uint8
+
+const (
+	SourceLinkLayerAddress NDPOptionType = 1
+	TargetLinkLayerAddress NDPOptionType = 2
+	PrefixInformation      NDPOptionType = 3
+	RedirectedHeader       NDPOptionType = 4
+	MTU                    NDPOptionType = 5
+)
+
+// NDPOption option of NDP messages, Length being in 8 bytes unit including Type and Length. Type is required for
+// link-layer addresses, being either source or target.
+type NDPOption struct {
+	Type   NDPOptionType `packet:"tlv=type"`
+	Length uint8         `packet:"tlv=length"`
+	Value  interface{}   `packet:"tlv=value"`
+}
+
+var ndpOptionSpec = &packet.TLVSpec{
+	Inclusive: true,
+	Unit:      8,
+	Registry: map[uint64]interface{}{
+		uint64(SourceLinkLayerAddress): &LinkLayerAddress{},
+		uint64(TargetLinkLayerAddress): &LinkLayerAddress{},
+		uint64(PrefixInformation):      &PrefixInformationOption{},
+		uint64(MTU):                    &MTUOption{},
+	},
+}
+
+// TLV returns the TLV spec of NDP options
+func (o NDPOption) TLV() *packet.TLVSpec {
+	return ndpOptionSpec
+}
+
+// LinkLayerAddress source or target link-layer address option
+type LinkLayerAddress struct {
+	Address Mac
+}
+
+// PrefixInformationOption prefix information option
+type PrefixInformationOption struct {
+	PrefixLength      uint8
+	OnLink            bool
+	Autonomous        bool
+	Reserved1         uint8 `packet:"length=6b"`
+	ValidLifetime     uint32
+	PreferredLifetime uint32
+	Reserved2         uint32
+	Prefix            net.IP `packet:"length=16B"`
+}
+
+// MTUOption MTU option
+type MTUOption struct {
+	Reserved uint16
+	MTU      uint32
+}
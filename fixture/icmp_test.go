@@ -0,0 +1,199 @@
+package fixture
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func serialize(t *testing.T, l ...gopacket.SerializableLayer) []byte {
+	buffer := gopacket.NewSerializeBuffer()
+	err := gopacket.SerializeLayers(buffer, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}, l...)
+	assert.NoError(t, err, "failed to serialize")
+	return buffer.Bytes()
+}
+
+func ipv4Layer(protocol layers.IPProtocol) *layers.IPv4 {
+	return &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: protocol,
+		SrcIP: net.IP{192, 168, 0, 1}, DstIP: net.IP{192, 168, 0, 2}}
+}
+
+func ipv6Layer(protocol layers.IPProtocol) *layers.IPv6 {
+	return &layers.IPv6{Version: 6, HopLimit: 255, NextHeader: protocol,
+		SrcIP: net.ParseIP("fe80::1"), DstIP: net.ParseIP("ff02::1:ff00:2")}
+}
+
+// checkChecksums decodes the packet, verifies the checksums, and checks the checksums computed from scratch encode the same data
+func checkChecksums(t *testing.T, ip interface {
+	UpdateChecksums() error
+	VerifyChecksums() (bool, error)
+}, b []byte, clear func()) {
+	assert.NoError(t, packet.Unmarshal(b, ip), "failed to decode")
+	ok, err := ip.VerifyChecksums()
+	assert.NoError(t, err, "failed to verify")
+	assert.True(t, ok, "valid checksums")
+
+	clear()
+	ok, _ = ip.VerifyChecksums()
+	assert.False(t, ok, "invalid checksums")
+	assert.NoError(t, ip.UpdateChecksums(), "failed to update")
+	encoded, err := packet.Marshal(ip)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+}
+
+func TestUDP(t *testing.T) {
+	ipv4 := ipv4Layer(layers.IPProtocolUDP)
+	udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ipv4)
+	b := serialize(t, ipv4, udp, gopacket.Payload("hello"))
+
+	ip := &IPv4{}
+	checkChecksums(t, ip, b, func() {
+		ip.Checksum = 0
+		ip.Body.(*UDP).Checksum = 0x1234
+	})
+	assert.Empty(t, cmp.Diff(&UDP{Source: 5000, Dest: 53, Length: 13, Checksum: ip.Body.(*UDP).Checksum,
+		Body: &packet.Raw{'h', 'e', 'l', 'l', 'o'}}, ip.Body), "diff found")
+}
+
+func TestICMPv4Echo(t *testing.T) {
+	ipv4 := ipv4Layer(layers.IPProtocolICMPv4)
+	icmp := &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeEchoRequest, 0), Id: 1, Seq: 2}
+	b := serialize(t, ipv4, icmp, gopacket.Payload("ping"))
+
+	ip := &IPv4{}
+	checkChecksums(t, ip, b, func() {
+		ip.Body.(*ICMPv4).Checksum = 0
+	})
+	icmpv4, ok := ip.Body.(*ICMPv4)
+	if assert.True(t, ok, "ICMPv4") {
+		assert.Equal(t, Echo, icmpv4.Type)
+		assert.Equal(t, &ICMPEcho{ID: 1, Sequence: 2, Data: []byte("ping")}, icmpv4.Body)
+	}
+}
+
+func TestICMPv4Unreachable(t *testing.T) {
+	original := ipv4Layer(layers.IPProtocolUDP)
+	original.SrcIP, original.DstIP = original.DstIP, original.SrcIP
+	udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(original)
+	datagram := serialize(t, original, udp, gopacket.Payload("hello"))
+
+	ipv4 := ipv4Layer(layers.IPProtocolICMPv4)
+	icmp := &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeDestinationUnreachable, layers.ICMPv4CodePort)}
+	b := serialize(t, ipv4, icmp, gopacket.Payload(datagram[:28]))
+
+	ip := &IPv4{}
+	checkChecksums(t, ip, b, func() {
+		ip.Body.(*ICMPv4).Checksum = 0
+	})
+	e, ok := ip.Body.(*ICMPv4).Body.(*ICMPv4Error)
+	if assert.True(t, ok, "ICMPv4 error") {
+		assert.Equal(t, net.IP{192, 168, 0, 2}, e.Original.Source)
+		assert.Equal(t, &packet.Raw{0x13, 0x88, 0x00, 0x35, 0x00, 0x0d, byte(udp.Checksum >> 8), byte(udp.Checksum)}, e.Original.Body)
+	}
+}
+
+func TestICMPv4UnreachableTCP(t *testing.T) {
+	original := ipv4Layer(layers.IPProtocolTCP)
+	original.SrcIP, original.DstIP = original.DstIP, original.SrcIP
+	tcp := &layers.TCP{SrcPort: 35000, DstPort: 179, Seq: 1, SYN: true, Window: 0xffff}
+	tcp.SetNetworkLayerForChecksum(original)
+	datagram := serialize(t, original, tcp)
+
+	ipv4 := ipv4Layer(layers.IPProtocolICMPv4)
+	icmp := &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(layers.ICMPv4TypeDestinationUnreachable, layers.ICMPv4CodePort)}
+	b := serialize(t, ipv4, icmp, gopacket.Payload(datagram[:28]))
+
+	ip := &IPv4{}
+	checkChecksums(t, ip, b, func() {
+		ip.Body.(*ICMPv4).Checksum = 0
+	})
+	e, ok := ip.Body.(*ICMPv4).Body.(*ICMPv4Error)
+	if assert.True(t, ok, "ICMPv4 error") {
+		assert.Equal(t, IPProtocol(6), e.Original.Protocol)
+		assert.Equal(t, &packet.Raw{0x88, 0xb8, 0x00, 0xb3, 0x00, 0x00, 0x00, 0x01}, e.Original.Body, "first 8 bytes of TCP")
+	}
+}
+
+func TestICMPv6NeighborSolicitation(t *testing.T) {
+	ipv6 := ipv6Layer(layers.IPProtocolICMPv6)
+	icmp := &layers.ICMPv6{TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeNeighborSolicitation, 0)}
+	icmp.SetNetworkLayerForChecksum(ipv6)
+	ns := &layers.ICMPv6NeighborSolicitation{
+		TargetAddress: net.ParseIP("fe80::2"),
+		Options: layers.ICMPv6Options{
+			layers.ICMPv6Option{Type: layers.ICMPv6OptSourceAddress, Data: []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}},
+		},
+	}
+	b := serialize(t, ipv6, icmp, ns)
+
+	ip := &IPv6{}
+	checkChecksums(t, ip, b, func() {
+		ip.Body.(*ICMPv6).Checksum = 0
+	})
+	assert.Empty(t, cmp.Diff(&NeighborSolicitationMessage{
+		Target: net.ParseIP("fe80::2"),
+		Options: []NDPOption{
+			NDPOption{Type: SourceLinkLayerAddress, Length: 1, Value: &LinkLayerAddress{Address: Mac{0x02, 0x00, 0x00, 0x00, 0x00, 0x01}}},
+		},
+	}, ip.Body.(*ICMPv6).Body), "diff found")
+}
+
+func TestICMPv6RouterAdvertisement(t *testing.T) {
+	ipv6 := ipv6Layer(layers.IPProtocolICMPv6)
+	icmp := &layers.ICMPv6{TypeCode: layers.CreateICMPv6TypeCode(layers.ICMPv6TypeRouterAdvertisement, 0)}
+	icmp.SetNetworkLayerForChecksum(ipv6)
+	prefix := []byte{64, 0xc0, 0x00, 0x00, 0x0e, 0x10, 0x00, 0x00, 0x07, 0x08, 0x00, 0x00, 0x00, 0x00,
+		0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	ra := &layers.ICMPv6RouterAdvertisement{
+		HopLimit:       64,
+		Flags:          0x80,
+		RouterLifetime: 1800,
+		// gopacket serializes the options in reverse order
+		Options: layers.ICMPv6Options{
+			layers.ICMPv6Option{Type: layers.ICMPv6OptPrefixInfo, Data: prefix},
+			layers.ICMPv6Option{Type: layers.ICMPv6OptMTU, Data: []byte{0x00, 0x00, 0x00, 0x00, 0x05, 0xdc}},
+		},
+	}
+	b := serialize(t, ipv6, icmp, ra)
+
+	ip := &IPv6{}
+	checkChecksums(t, ip, b, func() {
+		ip.Body.(*ICMPv6).Checksum = 0
+	})
+	assert.Empty(t, cmp.Diff(&RouterAdvertisementMessage{
+		CurHopLimit:    64,
+		Managed:        true,
+		RouterLifetime: 1800,
+		Options: []NDPOption{
+			NDPOption{Type: MTU, Length: 1, Value: &MTUOption{MTU: 1500}},
+			NDPOption{Type: PrefixInformation, Length: 4, Value: &PrefixInformationOption{
+				PrefixLength:      64,
+				OnLink:            true,
+				Autonomous:        true,
+				ValidLifetime:     3600,
+				PreferredLifetime: 1800,
+				Prefix:            net.ParseIP("2001:db8::"),
+			}},
+		},
+	}, ip.Body.(*ICMPv6).Body), "diff found")
+}
+
+func TestIPv6UDP(t *testing.T) {
+	ipv6 := ipv6Layer(layers.IPProtocolUDP)
+	udp := &layers.UDP{SrcPort: 546, DstPort: 547}
+	udp.SetNetworkLayerForChecksum(ipv6)
+	b := serialize(t, ipv6, udp, gopacket.Payload("dhcpv6"))
+
+	ip := &IPv6{}
+	checkChecksums(t, ip, b, func() {
+		ip.Body.(*UDP).Checksum = 0x1234
+	})
+}
@@ -0,0 +1,118 @@
+package fixture
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/nickchen/packet"
+)
+
+// IPv4OptionType type of IPv4 option, with the copied flag, class and number, https://www.iana.org/assignments/ip-parameters
+type IPv4OptionType uint8
+
+const (
+	IPv4EOL           IPv4OptionType = 0
+	IPv4NOP           IPv4OptionType = 1
+	RecordRoute       IPv4OptionType = 7
+	IPv4Timestamp     IPv4OptionType = 68
+	Security          IPv4OptionType = 130
+	LooseSourceRoute  IPv4OptionType = 131
+	StrictSourceRoute IPv4OptionType = 137
+	RouterAlert       IPv4OptionType = 148
+)
+
+func (t IPv4OptionType) String() string {
+	switch t {
+	case IPv4EOL:
+		return "EOL"
+	case IPv4NOP:
+		return "NOP"
+	case RecordRoute:
+		return "RR"
+	case IPv4Timestamp:
+		return "TS"
+	case Security:
+		return "SEC"
+	case LooseSourceRoute:
+		return "LSRR"
+	case StrictSourceRoute:
+		return "SSRR"
+	case RouterAlert:
+		return "RTRALT"
+	}
+	return fmt.Sprintf("IPv4OptionType(%d)", int(t))
+}
+
+// Copied returns true when the option is copied into all fragments
+func (t IPv4OptionType) Copied() bool {
+	return t&0x80 != 0
+}
+
+// IPv4Option option of IPv4, EOL and NOP being a single byte without Length and Value. Length includes Type and
+// Length. Type is required for the routes, being either record, loose or strict source route.
+type IPv4Option struct {
+	Type   IPv4OptionType `packet:"tlv=type"`
+	Length uint8          `packet:"tlv=length"`
+	Value  interface{}    `packet:"tlv=value"`
+}
+
+var ipv4OptionSpec = &packet.TLVSpec{
+	Padding:   []uint64{uint64(IPv4EOL), uint64(IPv4NOP)},
+	Inclusive: true,
+	Registry: map[uint64]interface{}{
+		uint64(RecordRoute):       &RouteOption{},
+		uint64(IPv4Timestamp):     &TimestampOption{},
+		uint64(Security):          &SecurityOption{},
+		uint64(LooseSourceRoute):  &RouteOption{},
+		uint64(StrictSourceRoute): &RouteOption{},
+		uint64(RouterAlert):       &RouterAlertOption{},
+	},
+}
+
+// TLV returns the TLV spec of IPv4 options
+func (o IPv4Option) TLV() *packet.TLVSpec {
+	return ipv4OptionSpec
+}
+
+// IPv4Address address of IPv4 options
+type IPv4Address [4]byte
+
+func (a IPv4Address) String() string {
+	return net.IP(a[:]).String()
+}
+
+// RouteOption record route, loose or strict source route, https://tools.ietf.org/html/rfc791, Pointer being the
+// offset in bytes from the Type to the next address, starting at 4
+type RouteOption struct {
+	Pointer uint8
+	Route   []IPv4Address
+}
+
+// TimestampFlag flag of the timestamp option
+type TimestampFlag uint8
+
+const (
+	TimestampOnly         TimestampFlag = 0
+	TimestampAndAddress   TimestampFlag = 1
+	TimestampPrespecified TimestampFlag = 3
+)
+
+// TimestampOption internet timestamp, https://tools.ietf.org/html/rfc791, Data being the timestamps, or the pairs of
+// address and timestamp depending on the Flag
+type TimestampOption struct {
+	Pointer  uint8
+	Overflow uint8         `packet:"length=4b"`
+	Flag     TimestampFlag `packet:"length=4b"`
+	Data     []uint32
+}
+
+// SecurityOption basic security, https://tools.ietf.org/html/rfc1108
+type SecurityOption struct {
+	Classification uint8
+	Authority      []byte
+}
+
+// RouterAlertOption router alert, https://tools.ietf.org/html/rfc2113, Value 0 being examine packet
+type RouterAlertOption struct {
+	Value uint16
+}
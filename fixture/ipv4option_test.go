@@ -0,0 +1,67 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPv4Options(t *testing.T) {
+	ipv4 := ipv4Layer(layers.IPProtocolUDP)
+	ipv4.Options = []layers.IPv4Option{
+		layers.IPv4Option{OptionType: 148, OptionLength: 4, OptionData: []byte{0x00, 0x00}},
+		layers.IPv4Option{OptionType: 1},
+		layers.IPv4Option{OptionType: 7, OptionLength: 11, OptionData: []byte{0x08, 10, 0, 0, 1, 0, 0, 0, 0}},
+		layers.IPv4Option{OptionType: 68, OptionLength: 12, OptionData: []byte{0x09, 0x10, 0x00, 0x00, 0x00, 0x64, 0x00, 0x00, 0x00, 0x00}},
+	}
+	udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ipv4)
+	b := serialize(t, ipv4, udp)
+	assert.Equal(t, uint8(12), b[0]&0x0f, "IHL")
+
+	ip := &IPv4{}
+	assert.NoError(t, packet.Unmarshal(b, ip), "failed to decode")
+	assert.Equal(t, uint8(12), ip.IHL)
+	assert.Empty(t, cmp.Diff([]IPv4Option{
+		IPv4Option{Type: RouterAlert, Length: 4, Value: &RouterAlertOption{}},
+		IPv4Option{Type: IPv4NOP},
+		IPv4Option{Type: RecordRoute, Length: 11, Value: &RouteOption{Pointer: 8, Route: []IPv4Address{{10, 0, 0, 1}, {0, 0, 0, 0}}}},
+		IPv4Option{Type: IPv4Timestamp, Length: 12, Value: &TimestampOption{Pointer: 9, Overflow: 1, Flag: TimestampOnly, Data: []uint32{100, 0}}},
+	}, ip.Options), "diff found")
+	assert.IsType(t, &UDP{}, ip.Body, "body after the options")
+
+	encoded, err := packet.Marshal(ip)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+
+	// IHL and padding are recalculated from the options, the IHL of the decoded options being stale
+	ip.Checksum = 0
+	ip.Options = ip.Options[2:3]
+	ip.Length -= 16
+	assert.NoError(t, ip.UpdateChecksums(), "failed to update")
+
+	ipv4.Options = ipv4.Options[2:3]
+	b = serialize(t, ipv4, udp)
+	encoded, err = packet.Marshal(ip)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+	assert.Equal(t, uint8(12), ip.IHL, "not changed")
+}
+
+func TestIPv4OptionsTruncated(t *testing.T) {
+	b := serialize(t, ipv4Layer(layers.IPProtocolIPv4))
+	b[0] = 0x46
+	ip := &IPv4{}
+	assert.IsType(t, &packet.UnmarshalUnexpectedEnd{}, packet.Unmarshal(b, ip))
+
+	b = append(b, 0x07, 0x07, 0x04, 0x0a)
+	ip = &IPv4{}
+	assert.IsType(t, &packet.UnmarshalUnexpectedEnd{}, packet.Unmarshal(b, ip))
+
+	assert.Equal(t, "10.0.0.1", IPv4Address{10, 0, 0, 1}.String())
+	assert.True(t, StrictSourceRoute.Copied())
+	assert.False(t, RecordRoute.Copied())
+}
@@ -0,0 +1,216 @@
+package fixture
+
+import (
+	"net"
+
+	"github.com/nickchen/packet"
+)
+
+// IPv6 packet as per https://tools.ietf.org/html/rfc8200#section-3, with the extension headers chained in Body
+type IPv6 struct {
+	Version       uint8  `packet:"length=4b"`
+	TrafficClass  uint8  `packet:"length=8b"`
+	FlowLabel     uint32 `packet:"length=20b"`
+	PayloadLength uint16
+	NextHeader    IPProtocol
+	HopLimit      uint8
+	Source        net.IP `packet:"length=16B"`
+	Dest          net.IP `packet:"length=16B"`
+	Body          interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (ip IPv6) InstanceFor(fieldname string) interface{} {
+	return bodyStructIPProtocol(ip.NextHeader)
+}
+
+// InstanceType returns the NextHeader for the Body struct pointer
+func (ip IPv6) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	return nextHeaderBodyStruct(body)
+}
+
+func nextHeaderBodyStruct(body interface{}) (string, uint64) {
+	if p, ok := ipProtocolBodyStruct(body); ok {
+		return "NextHeader", uint64(p)
+	}
+	return "", 0
+}
+
+// IPv6Option option of Hop-by-Hop and Destination Options headers, Pad1 being a single byte without Length and Data
+type IPv6Option struct {
+	Type   uint8  `packet:"tlv=type"`
+	Length uint8  `packet:"tlv=length"`
+	Data   []byte `packet:"tlv=value"`
+}
+
+// Pad1 and PadN option types
+const (
+	Pad1 uint8 = 0
+	PadN uint8 = 1
+)
+
+var ipv6OptionSpec = &packet.TLVSpec{
+	Padding: []uint64{uint64(Pad1)},
+}
+
+// TLV returns the TLV spec of IPv6 options
+func (o IPv6Option) TLV() *packet.TLVSpec {
+	return ipv6OptionSpec
+}
+
+// IPv6Options Hop-by-Hop Options and Destination Options headers, https://tools.ietf.org/html/rfc8200#section-4.3
+type IPv6Options struct {
+	NextHeader IPProtocol
+	HdrExtLen  uint8
+	Options    []IPv6Option `packet:"lengthfor"`
+	Body       interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (o IPv6Options) InstanceFor(fieldname string) interface{} {
+	return bodyStructIPProtocol(o.NextHeader)
+}
+
+// InstanceType returns the NextHeader for the Body struct pointer
+func (o IPv6Options) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	return nextHeaderBodyStruct(body)
+}
+
+// LengthFor returns the length in bytes for the provided field, HdrExtLen being in 8 bytes unit not including the first 8 bytes
+func (o IPv6Options) LengthFor(fieldname string) uint64 {
+	return 8*uint64(o.HdrExtLen) + 6
+}
+
+// HopByHop Hop-by-Hop Options header
+type HopByHop struct {
+	IPv6Options
+}
+
+// DestinationOptions Destination Options header
+type DestinationOptions struct {
+	IPv6Options
+}
+
+// RoutingType type of the Routing header
+type RoutingType uint8
+
+const (
+	// SegmentRoutingType Segment Routing Header, https://tools.ietf.org/html/rfc8754
+	SegmentRoutingType RoutingType = 4
+)
+
+// Routing Routing header, https://tools.ietf.org/html/rfc8200#section-4.4
+type Routing struct {
+	NextHeader   IPProtocol
+	HdrExtLen    uint8
+	RoutingType  RoutingType
+	SegmentsLeft uint8
+	Data         interface{} `packet:"lengthfor"`
+	Body         interface{}
+}
+
+// InstanceFor returns the Data or Body struct pointer for conversion
+func (r Routing) InstanceFor(fieldname string) interface{} {
+	switch fieldname {
+	case "Data":
+		switch r.RoutingType {
+		case SegmentRoutingType:
+			return &SegmentRouting{}
+		}
+		return nil
+	}
+	return bodyStructIPProtocol(r.NextHeader)
+}
+
+// InstanceType returns the RoutingType for the Data, or the NextHeader for the Body struct pointer
+func (r Routing) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	switch fieldname {
+	case "Data":
+		switch body.(type) {
+		case *SegmentRouting:
+			return "RoutingType", uint64(SegmentRoutingType)
+		}
+		return "", 0
+	}
+	return nextHeaderBodyStruct(body)
+}
+
+// LengthFor returns the length in bytes for the provided field
+func (r Routing) LengthFor(fieldname string) uint64 {
+	return 8*uint64(r.HdrExtLen) + 4
+}
+
+// Segment IPv6 address of the segment list
+type Segment [16]byte
+
+func (s Segment) String() string {
+	return net.IP(s[:]).String()
+}
+
+// SegmentRouting type specific data of Segment Routing Header, https://tools.ietf.org/html/rfc8754#section-2
+type SegmentRouting struct {
+	LastEntry uint8
+	Flags     uint8
+	Tag       uint16
+	Segments  []Segment `packet:"lengthfor"`
+	TLVs      []byte
+}
+
+// LengthFor returns the length in bytes for the provided field
+func (s SegmentRouting) LengthFor(fieldname string) uint64 {
+	return 16 * (uint64(s.LastEntry) + 1)
+}
+
+// Fragment Fragment header, https://tools.ietf.org/html/rfc8200#section-4.5
+type Fragment struct {
+	NextHeader     IPProtocol
+	Reserved       uint8
+	FragmentOffset uint16 `packet:"length=13b"`
+	Res            uint8  `packet:"length=2b"`
+	More           bool
+	Identification uint32
+	Body           interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion, only the first fragment has the next header
+func (f Fragment) InstanceFor(fieldname string) interface{} {
+	if f.FragmentOffset != 0 {
+		return nil
+	}
+	return bodyStructIPProtocol(f.NextHeader)
+}
+
+// InstanceType returns the NextHeader for the Body struct pointer
+func (f Fragment) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	return nextHeaderBodyStruct(body)
+}
+
+// AH Authentication Header, https://tools.ietf.org/html/rfc4302#section-2
+type AH struct {
+	NextHeader IPProtocol
+	PayloadLen uint8
+	Reserved   uint16
+	SPI        uint32
+	Sequence   uint32
+	ICV        []byte `packet:"lengthfor"`
+	Body       interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (ah AH) InstanceFor(fieldname string) interface{} {
+	return bodyStructIPProtocol(ah.NextHeader)
+}
+
+// InstanceType returns the NextHeader for the Body struct pointer
+func (ah AH) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	return nextHeaderBodyStruct(body)
+}
+
+// LengthFor returns the length in bytes for the provided field, PayloadLen being in 4 bytes unit minus 2
+func (ah AH) LengthFor(fieldname string) uint64 {
+	length := 4 * (uint64(ah.PayloadLen) + 2)
+	if length < 12 {
+		return 0
+	}
+	return length - 12
+}
@@ -0,0 +1,121 @@
+package fixture
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+// testIPv6ExtensionHeaders IPv6 with Hop-by-Hop, Segment Routing Header, Fragment, Destination Options and AH,
+// ending with TCP SYN
+var testIPv6ExtensionHeaders = []byte{
+	0x61, 0x23, 0x45, 0x67, 0x00, 0x5c, 0x00, 0x40,
+	0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+	0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+	// Hop-by-Hop
+	0x2b, 0x00, 0x01, 0x04, 0x00, 0x00, 0x00, 0x00,
+	// Routing, Segment Routing Header
+	0x2c, 0x02, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+	// Fragment
+	0x3c, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x2a,
+	// Destination Options
+	0x33, 0x00, 0x00, 0x01, 0x03, 0x00, 0x00, 0x00,
+	// AH
+	0x06, 0x04, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x01,
+	0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa,
+	// TCP
+	0x04, 0xd2, 0x00, 0x50, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x50, 0x02, 0xff, 0xff,
+	0x00, 0x00, 0x00, 0x00,
+}
+
+var ipv6ExtensionHeaders = &IPv6{
+	Version:       6,
+	TrafficClass:  0x12,
+	FlowLabel:     0x34567,
+	PayloadLength: 92,
+	NextHeader:    _HopByHop,
+	HopLimit:      64,
+	Source:        net.ParseIP("2001:db8::1"),
+	Dest:          net.ParseIP("2001:db8::2"),
+	Body: &HopByHop{IPv6Options{
+		NextHeader: _Routing,
+		Options:    []IPv6Option{IPv6Option{Type: PadN, Length: 4, Data: []byte{0x00, 0x00, 0x00, 0x00}}},
+		Body: &Routing{
+			NextHeader:  _Fragment,
+			HdrExtLen:   2,
+			RoutingType: SegmentRoutingType,
+			Data: &SegmentRouting{
+				Segments: []Segment{Segment{0x20, 0x01, 0x0d, 0xb8, 15: 0x02}},
+			},
+			Body: &Fragment{
+				NextHeader:     _DestOpts,
+				More:           true,
+				Identification: 42,
+				Body: &DestinationOptions{IPv6Options{
+					NextHeader: _AH,
+					Options: []IPv6Option{
+						IPv6Option{Type: Pad1},
+						IPv6Option{Type: PadN, Length: 3, Data: []byte{0x00, 0x00, 0x00}},
+					},
+					Body: &AH{
+						NextHeader: _TCP,
+						PayloadLen: 4,
+						SPI:        256,
+						Sequence:   1,
+						ICV:        []byte{0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa, 0xaa},
+						Body: &TCP{
+							Source:     1234,
+							Dest:       80,
+							Sequence:   1,
+							DataOffset: 5,
+							Flags:      SYN,
+							WindowSize: 0xffff,
+						},
+					},
+				}},
+			},
+		},
+	}},
+}
+
+func TestIPv6ExtensionHeaders(t *testing.T) {
+	ip := &IPv6{}
+	err := packet.Unmarshal(testIPv6ExtensionHeaders, ip)
+	assert.NoError(t, err, "failed to decode")
+	assert.Empty(t, cmp.Diff(ipv6ExtensionHeaders, ip), "diff found")
+
+	b, err := packet.Marshal(ipv6ExtensionHeaders)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, testIPv6ExtensionHeaders, b, "same data")
+}
+
+func TestIPv6Fragment(t *testing.T) {
+	b := append([]byte{}, testIPv6ExtensionHeaders[:40]...)
+	b[5], b[6] = 0x10, byte(_Fragment)
+	b = append(b, 0x06, 0x00, 0x00, 0xa8, 0x00, 0x00, 0x00, 0x2a, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08)
+
+	ip := &IPv6{}
+	err := packet.Unmarshal(b, ip)
+	assert.NoError(t, err, "failed to decode")
+	f, ok := ip.Body.(*Fragment)
+	if assert.True(t, ok, "fragment header") {
+		assert.Equal(t, uint16(21), f.FragmentOffset, "offset in 8 bytes unit")
+		assert.Equal(t, &packet.Raw{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, f.Body, "non-first fragment")
+	}
+}
+
+func TestIPv4ExtensionHeaderProtocols(t *testing.T) {
+	payload := gopacket.Payload{0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	for _, p := range []IPProtocol{_HopByHop, _Routing, _Fragment, _DestOpts} {
+		b := serialize(t, ipv4Layer(layers.IPProtocol(p)), payload)
+		ip := &IPv4{}
+		assert.NoError(t, packet.Unmarshal(b, ip), "failed to decode")
+		assert.Equal(t, &packet.Raw{0x06, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, ip.Body, "protocol %d kept as raw", p)
+	}
+}
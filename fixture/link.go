@@ -0,0 +1,163 @@
+package fixture
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/nickchen/packet"
+)
+
+// LinkType link-layer header type of capture files, https://www.tcpdump.org/linktypes.html
+type LinkType uint32
+
+const (
+	LinkTypeNull      LinkType = 0
+	LinkTypeEthernet  LinkType = 1
+	LinkTypeRaw       LinkType = 101
+	LinkTypeLoop      LinkType = 108
+	LinkTypeLinuxSLL  LinkType = 113
+	LinkTypeIPv4      LinkType = 228
+	LinkTypeIPv6      LinkType = 229
+	LinkTypeLinuxSLL2 LinkType = 276
+)
+
+func (t LinkType) String() string {
+	switch t {
+	case LinkTypeNull:
+		return "NULL"
+	case LinkTypeEthernet:
+		return "ETHERNET"
+	case LinkTypeRaw:
+		return "RAW"
+	case LinkTypeLoop:
+		return "LOOP"
+	case LinkTypeLinuxSLL:
+		return "LINUX_SLL"
+	case LinkTypeIPv4:
+		return "IPV4"
+	case LinkTypeIPv6:
+		return "IPV6"
+	case LinkTypeLinuxSLL2:
+		return "LINUX_SLL2"
+	}
+	return fmt.Sprintf("LinkType(%d)", int(t))
+}
+
+// LinkTypeError link type not supported by DecodeLinkLayer
+type LinkTypeError struct {
+	LinkType LinkType
+}
+
+func (e *LinkTypeError) Error() string {
+	return fmt.Sprintf("fixture: link type %s not supported", e.LinkType)
+}
+
+// DecodeLinkLayer decodes the packet data by the link type of the capture, returning the struct of the link layer,
+// or IPv4 and IPv6 for the raw link types
+func DecodeLinkLayer(linkType LinkType, data []byte) (interface{}, error) {
+	var v interface{}
+	switch linkType {
+	case LinkTypeEthernet:
+		v = &EthernetII{}
+	case LinkTypeNull, LinkTypeLoop:
+		v = &Loopback{}
+	case LinkTypeLinuxSLL:
+		v = &LinuxSLL{}
+	case LinkTypeLinuxSLL2:
+		v = &LinuxSLL2{}
+	case LinkTypeRaw, LinkTypeIPv4, LinkTypeIPv6:
+		if v = bodyStructIPVersion(data); v == nil {
+			raw := packet.Raw(data)
+			return &raw, nil
+		}
+	default:
+		return nil, &LinkTypeError{LinkType: linkType}
+	}
+	return v, packet.Unmarshal(data, v)
+}
+
+// bodyStructIPVersion returns IPv4 or IPv6 by the version in the first nibble
+func bodyStructIPVersion(b []byte) interface{} {
+	if len(b) > 0 {
+		switch b[0] >> 4 {
+		case 4:
+			return &IPv4{}
+		case 6:
+			return &IPv6{}
+		}
+	}
+	return nil
+}
+
+// Loopback header of BSD loopback, Family being in host byte order for NULL and network byte order for LOOP
+type Loopback struct {
+	Family uint32
+	Body   interface{}
+}
+
+// AddressFamily returns the address family regardless of the byte order
+func (l Loopback) AddressFamily() uint32 {
+	if l.Family > 0xffff {
+		return bits.ReverseBytes32(l.Family)
+	}
+	return l.Family
+}
+
+// InstanceFor returns the Body struct pointer for conversion, AF_INET6 being 10, 24, 28 or 30 depending on the OS
+func (l Loopback) InstanceFor(fieldname string) interface{} {
+	switch l.AddressFamily() {
+	case 2:
+		return &IPv4{}
+	case 10, 24, 28, 30:
+		return &IPv6{}
+	}
+	return nil
+}
+
+// LinuxSLL Linux cooked capture header, https://www.tcpdump.org/linktypes/LINKTYPE_LINUX_SLL.html
+type LinuxSLL struct {
+	PacketType    uint16
+	ARPHRDType    uint16
+	AddressLength uint16
+	Address       [8]byte
+	Protocol      EtherType
+	Body          interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (l LinuxSLL) InstanceFor(fieldname string) interface{} {
+	return bodyStructEtherType(l.Protocol)
+}
+
+// InstanceType returns the Protocol for the Body struct pointer
+func (l LinuxSLL) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	if t := etherTypeBodyStruct(body); t != 0 {
+		return "Protocol", uint64(t)
+	}
+	return "", 0
+}
+
+// LinuxSLL2 Linux cooked capture v2 header, with the interface index, https://www.tcpdump.org/linktypes/LINKTYPE_LINUX_SLL2.html
+type LinuxSLL2 struct {
+	Protocol       EtherType
+	Reserved       uint16
+	InterfaceIndex uint32
+	ARPHRDType     uint16
+	PacketType     uint8
+	AddressLength  uint8
+	Address        [8]byte
+	Body           interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (l LinuxSLL2) InstanceFor(fieldname string) interface{} {
+	return bodyStructEtherType(l.Protocol)
+}
+
+// InstanceType returns the Protocol for the Body struct pointer
+func (l LinuxSLL2) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	if t := etherTypeBodyStruct(body); t != 0 {
+		return "Protocol", uint64(t)
+	}
+	return "", 0
+}
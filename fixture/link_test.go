@@ -0,0 +1,77 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeLinkLayer(t *testing.T) {
+	ipv4 := ipv4Layer(layers.IPProtocolUDP)
+	udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ipv4)
+	v4 := serialize(t, ipv4, udp, gopacket.Payload("hello"))
+	ipv6 := ipv6Layer(layers.IPProtocolUDP)
+	udp.SetNetworkLayerForChecksum(ipv6)
+	v6 := serialize(t, ipv6, udp, gopacket.Payload("hello"))
+
+	sll := []byte{0x00, 0x00, 0x00, 0x01, 0x00, 0x06, 0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77, 0x00, 0x00, 0x08, 0x00}
+	sll2 := []byte{0x86, 0xdd, 0x00, 0x00, 0x00, 0x00, 0x00, 0x03, 0x00, 0x01, 0x04, 0x06,
+		0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77, 0x00, 0x00}
+	for _, test := range []struct {
+		name     string
+		linkType LinkType
+		data     []byte
+		check    func(t *testing.T, v interface{})
+	}{
+		{name: "NULL", linkType: LinkTypeNull, data: append([]byte{0x02, 0x00, 0x00, 0x00}, v4...), check: func(t *testing.T, v interface{}) {
+			assert.Equal(t, uint32(2), v.(*Loopback).AddressFamily())
+			assert.IsType(t, &IPv4{}, v.(*Loopback).Body)
+		}},
+		{name: "LOOP", linkType: LinkTypeLoop, data: append([]byte{0x00, 0x00, 0x00, 0x1e}, v6...), check: func(t *testing.T, v interface{}) {
+			assert.IsType(t, &IPv6{}, v.(*Loopback).Body)
+		}},
+		{name: "LINUX_SLL", linkType: LinkTypeLinuxSLL, data: append(sll, v4...), check: func(t *testing.T, v interface{}) {
+			l := v.(*LinuxSLL)
+			gp := gopacket.NewPacket(append(sll, v4...), layers.LayerTypeLinuxSLL, gopacket.Default)
+			gpSLL, _ := gp.Layer(layers.LayerTypeLinuxSLL).(*layers.LinuxSLL)
+			assert.NotNil(t, gpSLL, "sll layer decoded")
+			assert.Equal(t, gpSLL.AddrLen, l.AddressLength)
+			assert.Equal(t, []byte(gpSLL.Addr), l.Address[:l.AddressLength])
+			assert.Equal(t, uint16(gpSLL.EthernetType), uint16(l.Protocol))
+			assert.IsType(t, &IPv4{}, l.Body)
+		}},
+		{name: "LINUX_SLL2", linkType: LinkTypeLinuxSLL2, data: append(sll2, v6...), check: func(t *testing.T, v interface{}) {
+			l := v.(*LinuxSLL2)
+			assert.Equal(t, uint32(3), l.InterfaceIndex)
+			assert.Equal(t, uint8(4), l.PacketType)
+			assert.IsType(t, &IPv6{}, l.Body)
+		}},
+		{name: "RAW", linkType: LinkTypeRaw, data: v4, check: func(t *testing.T, v interface{}) {
+			assert.IsType(t, &UDP{}, v.(*IPv4).Body)
+		}},
+		{name: "IPV6", linkType: LinkTypeIPv6, data: v6, check: func(t *testing.T, v interface{}) {
+			assert.IsType(t, &UDP{}, v.(*IPv6).Body)
+		}},
+		{name: "RAW unknown", linkType: LinkTypeRaw, data: []byte{0x00, 0x01}, check: func(t *testing.T, v interface{}) {
+			assert.Equal(t, &packet.Raw{0x00, 0x01}, v)
+		}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := DecodeLinkLayer(test.linkType, test.data)
+			assert.NoError(t, err, "failed to decode")
+			test.check(t, v)
+
+			encoded, err := packet.Marshal(v)
+			assert.NoError(t, err, "failed to encode")
+			assert.Equal(t, test.data, encoded, "same data")
+		})
+	}
+
+	_, err := DecodeLinkLayer(LinkType(147), v4)
+	assert.IsType(t, &LinkTypeError{}, err)
+	assert.Equal(t, "fixture: link type LinkType(147) not supported", err.Error())
+}
@@ -0,0 +1,149 @@
+package fixture
+
+import (
+	"fmt"
+
+	"github.com/nickchen/packet"
+)
+
+// LLDP link layer discovery protocol as per IEEE 802.1AB, ending with the End TLV, the padding of the frame after
+// it is not decoded
+type LLDP struct {
+	TLVs []LLDPTLV
+}
+
+// LLDPType type of LLDP TLV
+type LLDPType uint8
+
+const (
+	LLDPEnd                  LLDPType = 0
+	LLDPChassisID            LLDPType = 1
+	LLDPPortID               LLDPType = 2
+	LLDPTTL                  LLDPType = 3
+	LLDPPortDescription      LLDPType = 4
+	LLDPSystemName           LLDPType = 5
+	LLDPSystemDescription    LLDPType = 6
+	LLDPSystemCapabilities   LLDPType = 7
+	LLDPManagementAddress    LLDPType = 8
+	LLDPOrganizationSpecific LLDPType = 127
+)
+
+func (t LLDPType) String() string {
+	switch t {
+	case LLDPEnd:
+		return "End"
+	case LLDPChassisID:
+		return "ChassisID"
+	case LLDPPortID:
+		return "PortID"
+	case LLDPTTL:
+		return "TTL"
+	case LLDPPortDescription:
+		return "PortDescription"
+	case LLDPSystemName:
+		return "SystemName"
+	case LLDPSystemDescription:
+		return "SystemDescription"
+	case LLDPSystemCapabilities:
+		return "SystemCapabilities"
+	case LLDPManagementAddress:
+		return "ManagementAddress"
+	case LLDPOrganizationSpecific:
+		return "OrganizationSpecific"
+	}
+	return fmt.Sprintf("LLDPType(%d)", int(t))
+}
+
+// LLDPTLV TLV of LLDP, with 7 bits of Type and 9 bits of Length. Type is required for the IDs and the descriptions,
+// which share the Value struct.
+type LLDPTLV struct {
+	Type   LLDPType    `packet:"length=7b,tlv=type"`
+	Length uint16      `packet:"length=9b,tlv=length"`
+	Value  interface{} `packet:"tlv=value"`
+}
+
+var lldpSpec = &packet.TLVSpec{
+	End: []uint64{uint64(LLDPEnd)},
+	Registry: map[uint64]interface{}{
+		uint64(LLDPChassisID):            &LLDPID{},
+		uint64(LLDPPortID):               &LLDPID{},
+		uint64(LLDPTTL):                  &LLDPTimeToLive{},
+		uint64(LLDPPortDescription):      &LLDPString{},
+		uint64(LLDPSystemName):           &LLDPString{},
+		uint64(LLDPSystemDescription):    &LLDPString{},
+		uint64(LLDPSystemCapabilities):   &LLDPCapabilities{},
+		uint64(LLDPManagementAddress):    &LLDPManagement{},
+		uint64(LLDPOrganizationSpecific): &LLDPOrganization{},
+	},
+}
+
+// TLV returns the TLV spec of LLDP
+func (t LLDPTLV) TLV() *packet.TLVSpec {
+	return lldpSpec
+}
+
+// LLDPID chassis or port ID, the ID being a MAC address, network address or name depending on the Subtype
+type LLDPID struct {
+	Subtype uint8
+	ID      []byte
+}
+
+// LLDPTimeToLive time to live of the information in seconds
+type LLDPTimeToLive struct {
+	Seconds uint16
+}
+
+// LLDPString port description, system name or system description
+type LLDPString struct {
+	Value string
+}
+
+// LLDPCapability capabilities of the system
+type LLDPCapability uint16
+
+const (
+	LLDPOther LLDPCapability = 1 << iota
+	LLDPRepeater
+	LLDPBridge
+	LLDPWLANAP
+	LLDPRouter
+	LLDPTelephone
+	LLDPDocsis
+	LLDPStationOnly
+)
+
+// LLDPCapabilities system capabilities, along with the enabled ones
+type LLDPCapabilities struct {
+	Capabilities LLDPCapability
+	Enabled      LLDPCapability
+}
+
+// LLDPManagement management address, AddressLength including the Subtype
+type LLDPManagement struct {
+	AddressLength    uint8
+	Subtype          uint8
+	Address          []byte `packet:"lengthfor"`
+	InterfaceSubtype uint8
+	InterfaceNumber  uint32
+	OIDLength        uint8
+	OID              []byte `packet:"lengthfor"`
+}
+
+// LengthFor returns the length in bytes for the provided field
+func (m LLDPManagement) LengthFor(fieldname string) uint64 {
+	switch fieldname {
+	case "Address":
+		if m.AddressLength == 0 {
+			return 0
+		}
+		return uint64(m.AddressLength) - 1
+	}
+	return uint64(m.OIDLength)
+}
+
+// LLDPOrganization organizationally specific TLV
+type LLDPOrganization struct {
+	OUI     [3]byte
+	Subtype uint8
+	Info    []byte
+}
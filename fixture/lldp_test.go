@@ -0,0 +1,94 @@
+package fixture
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLLDP(t *testing.T) {
+	description := bytes.Repeat([]byte{'x'}, 300)
+	b := []byte{
+		0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e, 0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77, 0x88, 0xcc,
+		0x02, 0x07, 0x04, 0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77,
+		0x04, 0x05, 0x05, 'e', 't', 'h', '0',
+		0x06, 0x02, 0x00, 0x78,
+		0x0a, 0x06, 's', 'w', 'i', 't', 'c', 'h',
+		0x0d, 0x2c,
+	}
+	b = append(b, description...)
+	b = append(b,
+		0x0e, 0x04, 0x00, 0x14, 0x00, 0x10,
+		0x10, 0x0c, 0x05, 0x01, 0xc0, 0xa8, 0x00, 0x01, 0x02, 0x00, 0x00, 0x00, 0x03, 0x00,
+		0xfe, 0x06, 0x00, 0x80, 0xc2, 0x01, 0x00, 0x64,
+		0x00, 0x00,
+	)
+	tlvs := []LLDPTLV{
+		LLDPTLV{Type: LLDPChassisID, Length: 7, Value: &LLDPID{Subtype: 4, ID: []byte{0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77}}},
+		LLDPTLV{Type: LLDPPortID, Length: 5, Value: &LLDPID{Subtype: 5, ID: []byte("eth0")}},
+		LLDPTLV{Type: LLDPTTL, Length: 2, Value: &LLDPTimeToLive{Seconds: 120}},
+		LLDPTLV{Type: LLDPSystemName, Length: 6, Value: &LLDPString{Value: "switch"}},
+		LLDPTLV{Type: LLDPSystemDescription, Length: 300, Value: &LLDPString{Value: string(description)}},
+		LLDPTLV{Type: LLDPSystemCapabilities, Length: 4, Value: &LLDPCapabilities{Capabilities: LLDPBridge | LLDPRouter, Enabled: LLDPRouter}},
+		LLDPTLV{Type: LLDPManagementAddress, Length: 12, Value: &LLDPManagement{AddressLength: 5, Subtype: 1,
+			Address: []byte{192, 168, 0, 1}, InterfaceSubtype: 2, InterfaceNumber: 3, OID: []byte{}}},
+		LLDPTLV{Type: LLDPOrganizationSpecific, Length: 6, Value: &LLDPOrganization{OUI: [3]byte{0x00, 0x80, 0xc2}, Subtype: 1, Info: []byte{0x00, 0x64}}},
+		LLDPTLV{Type: LLDPEnd},
+	}
+
+	ether := &EthernetII{}
+	assert.NoError(t, packet.Unmarshal(b, ether), "failed to decode")
+	lldp, ok := ether.Body.(*LLDP)
+	assert.True(t, ok, "failed to find LLDP")
+	assert.Empty(t, cmp.Diff(tlvs, lldp.TLVs, cmp.Comparer(bytes.Equal)), "diff found")
+
+	gp := gopacket.NewPacket(b, layers.LayerTypeEthernet, gopacket.Default)
+	gpLLDP, _ := gp.Layer(layers.LayerTypeLinkLayerDiscovery).(*layers.LinkLayerDiscovery)
+	assert.NotNil(t, gpLLDP, "lldp layer decoded")
+	assert.Equal(t, len(gpLLDP.Values)+4, len(lldp.TLVs), "same number of TLVs")
+	for i, v := range gpLLDP.Values {
+		assert.Equal(t, uint8(v.Type), uint8(lldp.TLVs[i+3].Type), "same type")
+		assert.Equal(t, v.Length, lldp.TLVs[i+3].Length, "same length")
+	}
+
+	encoded, err := packet.Marshal(ether)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+
+	// lengths are filled in
+	for i := range lldp.TLVs {
+		lldp.TLVs[i].Length = 0
+	}
+	encoded, err = packet.Marshal(ether)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+}
+
+func TestLLDPPadding(t *testing.T) {
+	b := []byte{
+		0x01, 0x80, 0xc2, 0x00, 0x00, 0x0e, 0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77, 0x88, 0xcc,
+		0x02, 0x07, 0x04, 0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77,
+		0x04, 0x05, 0x05, 'e', 't', 'h', '0',
+		0x06, 0x02, 0x00, 0x78,
+		0x00, 0x00,
+	}
+	tlvs := []LLDPTLV{
+		LLDPTLV{Type: LLDPChassisID, Length: 7, Value: &LLDPID{Subtype: 4, ID: []byte{0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77}}},
+		LLDPTLV{Type: LLDPPortID, Length: 5, Value: &LLDPID{Subtype: 5, ID: []byte("eth0")}},
+		LLDPTLV{Type: LLDPTTL, Length: 2, Value: &LLDPTimeToLive{Seconds: 120}},
+		LLDPTLV{Type: LLDPEnd},
+	}
+	for _, padding := range [][]byte{bytes.Repeat([]byte{0x00}, 24), bytes.Repeat([]byte{0xaa}, 24)} {
+		ether := &EthernetII{}
+		assert.NoError(t, packet.Unmarshal(append(append([]byte{}, b...), padding...), ether), "failed to decode")
+		lldp, ok := ether.Body.(*LLDP)
+		if assert.True(t, ok, "failed to find LLDP") {
+			assert.Empty(t, cmp.Diff(tlvs, lldp.TLVs, cmp.Comparer(bytes.Equal)), "padding after End")
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package fixture
+
+import "github.com/nickchen/packet"
+
+// MPLS label stack entry as per https://tools.ietf.org/html/rfc3032#section-2.1, Body being the next entry until
+// the bottom of the stack, then IPv4 or IPv6 by the version in the first nibble
+type MPLS struct {
+	Label  uint32 `packet:"length=20b"`
+	TC     uint8  `packet:"length=3b"`
+	Bottom bool
+	TTL    uint8
+	Body   interface{}
+}
+
+// InstanceForContext returns the Body struct pointer for conversion, peeking at the payload after the bottom of the stack
+func (m MPLS) InstanceForContext(ctx *packet.Context, fieldname string) interface{} {
+	if !m.Bottom {
+		return &MPLS{}
+	}
+	return bodyStructIPVersion(ctx.Bytes())
+}
+
+// Labels returns the labels of the stack, from top to bottom
+func (m *MPLS) Labels() []uint32 {
+	var labels []uint32
+	for e := m; e != nil; {
+		labels = append(labels, e.Label)
+		if e.Bottom {
+			break
+		}
+		e, _ = e.Body.(*MPLS)
+	}
+	return labels
+}
@@ -0,0 +1,48 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMPLS(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		ip   gopacket.SerializableLayer
+		body interface{}
+	}{
+		{name: "IPv4", ip: ipv4Layer(layers.IPProtocolUDP), body: &IPv4{}},
+		{name: "IPv6", ip: ipv6Layer(layers.IPProtocolUDP), body: &IPv6{}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+			udp.SetNetworkLayerForChecksum(test.ip.(gopacket.NetworkLayer))
+			b := serialize(t, &layers.MPLS{Label: 100, TrafficClass: 5, TTL: 64},
+				&layers.MPLS{Label: 200, StackBottom: true, TTL: 63}, test.ip, udp)
+
+			m := &MPLS{}
+			assert.NoError(t, packet.Unmarshal(b, m), "failed to decode")
+			assert.Equal(t, []uint32{100, 200}, m.Labels())
+			assert.Equal(t, uint8(5), m.TC)
+			bottom := m.Body.(*MPLS)
+			assert.True(t, bottom.Bottom, "bottom of stack")
+			assert.Equal(t, uint8(63), bottom.TTL)
+			assert.IsType(t, test.body, bottom.Body, "body after the stack")
+
+			encoded, err := packet.Marshal(m)
+			assert.NoError(t, err, "failed to encode")
+			assert.Equal(t, b, encoded, "same data")
+		})
+	}
+}
+
+func TestMPLSRaw(t *testing.T) {
+	b := []byte{0x00, 0x06, 0x41, 0x40, 0x00, 0x01, 0x02}
+	m := &MPLS{}
+	assert.NoError(t, packet.Unmarshal(b, m), "failed to decode")
+	assert.Equal(t, &MPLS{Label: 100, Bottom: true, TTL: 64, Body: &packet.Raw{0x00, 0x01, 0x02}}, m)
+}
@@ -0,0 +1,89 @@
+package mrt
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/nickchen/packet"
+	"github.com/nickchen/packet/fixture/bgp"
+)
+
+// Peer the peering of BGP4MP records, where the size of the AS depends on the subtype,
+// and the size of the IP addresses depends on AFI
+type Peer struct {
+	PeerAS         bgp.ASN4 `packet:"lengthfor"`
+	LocalAS        bgp.ASN4 `packet:"lengthfor"`
+	InterfaceIndex uint16
+	AFI            bgp.AFI
+	PeerIP         net.IP `packet:"lengthfor"`
+	LocalIP        net.IP `packet:"lengthfor"`
+}
+
+// LengthForContext implementation of the LengthForContext interface, which returns length in bytes for the provided field
+func (p Peer) LengthForContext(ctx *packet.Context, fieldname string) uint64 {
+	switch fieldname {
+	case "PeerAS", "LocalAS":
+		if r := recordFor(ctx); r != nil && r.as4() {
+			return 4
+		}
+		return 2
+	}
+	if p.AFI == bgp.IPv6 {
+		return net.IPv6len
+	}
+	return net.IPv4len
+}
+
+// recordFor returns the enclosing record
+func recordFor(ctx *packet.Context) *Record {
+	for i := 0; i < ctx.Depth(); i++ {
+		if r, ok := ctx.Parent(i).(*Record); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// Message BGP4MP_MESSAGE, BGP4MP_MESSAGE_AS4 and the local variants, https://tools.ietf.org/html/rfc6396#section-4.4.2
+type Message struct {
+	Peer    Peer
+	Message bgp.Message
+}
+
+// State BGP finite state machine state, https://tools.ietf.org/html/rfc4271#section-8.2.2
+type State uint16
+
+// BGP states
+const (
+	Idle State = 1 + iota
+	Connect
+	Active
+	OpenSent
+	OpenConfirm
+	Established
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "Idle"
+	case Connect:
+		return "Connect"
+	case Active:
+		return "Active"
+	case OpenSent:
+		return "OpenSent"
+	case OpenConfirm:
+		return "OpenConfirm"
+	case Established:
+		return "Established"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// StateChange BGP4MP_STATE_CHANGE and BGP4MP_STATE_CHANGE_AS4, https://tools.ietf.org/html/rfc6396#section-4.4.1
+type StateChange struct {
+	Peer     Peer
+	OldState State
+	NewState State
+}
@@ -0,0 +1,151 @@
+// Package mrt decodes and encodes Multi-Threaded Routing Toolkit (MRT) routing information export format,
+// as defined in https://tools.ietf.org/html/rfc6396, with the embedded BGP messages and attributes from package bgp.
+package mrt
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nickchen/packet/fixture/bgp"
+)
+
+// Type type of MRT record
+type Type uint16
+
+const (
+	// TableDumpV2 RIB dump, https://tools.ietf.org/html/rfc6396#section-4.3
+	TableDumpV2 Type = 13
+	// BGP4MP BGP messages and state changes, https://tools.ietf.org/html/rfc6396#section-4.4
+	BGP4MP Type = 16
+	// BGP4MPET BGP4MP with microsecond timestamp, https://tools.ietf.org/html/rfc6396#section-3
+	BGP4MPET Type = 17
+)
+
+func (t Type) String() string {
+	switch t {
+	case TableDumpV2:
+		return "TABLE_DUMP_V2"
+	case BGP4MP:
+		return "BGP4MP"
+	case BGP4MPET:
+		return "BGP4MP_ET"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// extended types with the microsecond timestamp
+func (t Type) extended() bool {
+	return t == BGP4MPET
+}
+
+// Subtypes of TABLE_DUMP_V2, https://tools.ietf.org/html/rfc6396#section-4.3
+const (
+	PeerIndexTableSubtype   uint16 = 1
+	RIBIPv4UnicastSubtype   uint16 = 2
+	RIBIPv4MulticastSubtype uint16 = 3
+	RIBIPv6UnicastSubtype   uint16 = 4
+	RIBIPv6MulticastSubtype uint16 = 5
+)
+
+// Subtypes of BGP4MP, https://tools.ietf.org/html/rfc6396#section-4.4
+const (
+	StateChangeSubtype     uint16 = 0
+	MessageSubtype         uint16 = 1
+	MessageAS4Subtype      uint16 = 4
+	StateChangeAS4Subtype  uint16 = 5
+	MessageLocalSubtype    uint16 = 6
+	MessageAS4LocalSubtype uint16 = 7
+)
+
+// HeaderSize the size of the MRT common header
+const HeaderSize = 12
+
+// Record MRT record with the common header, https://tools.ietf.org/html/rfc6396#section-2.
+// Microseconds is only present for the extended types, and is counted in Length. Marshal infers the Type from the
+// Message, but the Subtype has to be set, as it's the address family of RIB and the AS size of BGP4MP, which the
+// Message doesn't carry.
+type Record struct {
+	Timestamp    uint32
+	Type         Type
+	Subtype      uint16
+	Length       uint32
+	Microseconds uint32      `packet:"lengthfor"`
+	Message      interface{} `packet:"lengthfor"`
+}
+
+// Time returns the timestamp of the record
+func (r Record) Time() time.Time {
+	return time.Unix(int64(r.Timestamp), int64(r.Microseconds)*int64(time.Microsecond))
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (r Record) LengthFor(fieldname string) uint64 {
+	extended := uint64(0)
+	if r.Type.extended() {
+		extended = 4
+	}
+	switch fieldname {
+	case "Microseconds":
+		return extended
+	case "Message":
+		if uint64(r.Length) > extended {
+			return uint64(r.Length) - extended
+		}
+	}
+	return 0
+}
+
+// InstanceFor interface implementation to provide struct for the message
+func (r Record) InstanceFor(fieldname string) interface{} {
+	switch r.Type {
+	case TableDumpV2:
+		switch r.Subtype {
+		case PeerIndexTableSubtype:
+			return &PeerIndexTable{}
+		case RIBIPv4UnicastSubtype, RIBIPv4MulticastSubtype, RIBIPv6UnicastSubtype, RIBIPv6MulticastSubtype:
+			return &RIB{}
+		}
+	case BGP4MP, BGP4MPET:
+		switch r.Subtype {
+		case StateChangeSubtype, StateChangeAS4Subtype:
+			return &StateChange{}
+		case MessageSubtype, MessageAS4Subtype, MessageLocalSubtype, MessageAS4LocalSubtype:
+			return &Message{}
+		}
+	}
+	return nil
+}
+
+// InstanceType interface implementation to provide the type for the message, the subtype isn't inferred
+func (r Record) InstanceType(fieldname string, message interface{}) (string, uint64) {
+	switch message.(type) {
+	case *PeerIndexTable, *RIB:
+		return "Type", uint64(TableDumpV2)
+	case *StateChange, *Message:
+		return "Type", uint64(BGP4MP)
+	}
+	return "", 0
+}
+
+// BGPSession implementation of the bgp.SessionProvider interface, which returns the session for the embedded BGP data.
+// The session is new for each call, as it's changed by Observe.
+func (r Record) BGPSession() *bgp.Session {
+	switch {
+	case r.Type == TableDumpV2:
+		// RIB entries have 4-octet AS and abbreviated MP_REACH_NLRI, https://tools.ietf.org/html/rfc6396#section-4.3.4
+		return &bgp.Session{FourOctetAS: true, AbbreviatedMPReach: true}
+	case r.as4():
+		// BGP messages of the AS4 subtypes
+		return &bgp.Session{FourOctetAS: true}
+	}
+	return nil
+}
+
+// as4 whether the BGP4MP record has 4-octet AS
+func (r Record) as4() bool {
+	switch r.Subtype {
+	case MessageAS4Subtype, StateChangeAS4Subtype, MessageAS4LocalSubtype:
+		return r.Type == BGP4MP || r.Type == BGP4MPET
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package mrt
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nickchen/packet"
+	"github.com/nickchen/packet/fixture/bgp"
+	"github.com/stretchr/testify/assert"
+)
+
+// testPeerIndexTable PEER_INDEX_TABLE with an IPv4 peer with 4-octet AS, and an IPv6 peer with 2-octet AS
+var testPeerIndexTable = []byte{
+	0x5f, 0x5e, 0x10, 0x00, 0x00, 0x0d, 0x00, 0x01, 0x00, 0x00, 0x00, 0x30,
+	0x0a, 0x00, 0x00, 0x01, 0x00, 0x04, 0x74, 0x65, 0x73, 0x74, 0x00, 0x02,
+	0x02, 0xc0, 0xa8, 0x00, 0x01, 0xc0, 0xa8, 0x00, 0x01, 0x00, 0x00, 0xfd, 0xe8,
+	0x01, 0xc0, 0xa8, 0x00, 0x02, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xfd, 0xe9,
+}
+
+var peerIndexTable = &Record{
+	Timestamp: 1600000000,
+	Type:      TableDumpV2,
+	Subtype:   PeerIndexTableSubtype,
+	Length:    48,
+	Message: &PeerIndexTable{
+		CollectorBGPID: net.IP{0x0a, 0x00, 0x00, 0x01},
+		ViewNameLength: 4,
+		ViewName:       "test",
+		PeerCount:      2,
+		Peers: []PeerEntry{
+			PeerEntry{Type: PeerAS4, BGPID: net.IP{0xc0, 0xa8, 0x00, 0x01}, IP: net.IP{0xc0, 0xa8, 0x00, 0x01}, AS: 65000},
+			PeerEntry{Type: PeerIPv6, BGPID: net.IP{0xc0, 0xa8, 0x00, 0x02}, IP: net.ParseIP("2001:db8::2"), AS: 65001},
+		},
+	},
+}
+
+// testRIBIPv4Unicast RIB_IPV4_UNICAST with 4-octet AS_PATH
+var testRIBIPv4Unicast = []byte{
+	0x5f, 0x5e, 0x10, 0x00, 0x00, 0x0d, 0x00, 0x02, 0x00, 0x00, 0x00, 0x2a,
+	0x00, 0x00, 0x00, 0x00, 0x18, 0x0a, 0x01, 0x02, 0x00, 0x01,
+	0x00, 0x00, 0x5f, 0x5e, 0x0f, 0x00, 0x00, 0x18,
+	0x40, 0x01, 0x01, 0x00,
+	0x40, 0x02, 0x0a, 0x02, 0x02, 0x00, 0x00, 0xfd, 0xe8, 0x00, 0x01, 0x00, 0x00,
+	0x40, 0x03, 0x04, 0xc0, 0xa8, 0x00, 0x01,
+}
+
+var ribIPv4Unicast = &Record{
+	Timestamp: 1600000000,
+	Type:      TableDumpV2,
+	Subtype:   RIBIPv4UnicastSubtype,
+	Length:    42,
+	Message: &RIB{
+		SequenceNumber: 0,
+		Prefix:         bgp.PrefixSpec{Length: 24, Prefix: []byte{0x0a, 0x01, 0x02}},
+		EntryCount:     1,
+		Entries: []RIBEntry{
+			RIBEntry{
+				PeerIndex:       0,
+				OriginatedTime:  1599999744,
+				AttributeLength: 24,
+				Attributes: []bgp.PathAttribute{
+					bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.Origin, Length: 1, Data: &bgp.OriginAttribute{Origin: bgp.IBGP}},
+					bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.AsPath, Length: 10, Data: &[]bgp.AsPathAttribute{
+						bgp.AsPathAttribute{Type: bgp.AsSequence, Count: 2, List: []bgp.ASN4{65000, 65536}},
+					}},
+					bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.Nexthop, Length: 4, Data: &bgp.NexthopAttribute{Nexthop: []byte{0xc0, 0xa8, 0x00, 0x01}}},
+				},
+			},
+		},
+	},
+}
+
+// testRIBIPv6Unicast RIB_IPV6_UNICAST with abbreviated MP_REACH_NLRI
+var testRIBIPv6Unicast = []byte{
+	0x5f, 0x5e, 0x10, 0x00, 0x00, 0x0d, 0x00, 0x04, 0x00, 0x00, 0x00, 0x2b,
+	0x00, 0x00, 0x00, 0x01, 0x20, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x01,
+	0x00, 0x01, 0x5f, 0x5e, 0x0f, 0x00, 0x00, 0x18,
+	0x40, 0x01, 0x01, 0x00,
+	0x80, 0x0e, 0x11, 0x10, 0x20, 0x01, 0x0d, 0xb8, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02,
+}
+
+var ribIPv6Unicast = &Record{
+	Timestamp: 1600000000,
+	Type:      TableDumpV2,
+	Subtype:   RIBIPv6UnicastSubtype,
+	Length:    43,
+	Message: &RIB{
+		SequenceNumber: 1,
+		Prefix:         bgp.PrefixSpec{Length: 32, Prefix: []byte{0x20, 0x01, 0x0d, 0xb8}},
+		EntryCount:     1,
+		Entries: []RIBEntry{
+			RIBEntry{
+				PeerIndex:       1,
+				OriginatedTime:  1599999744,
+				AttributeLength: 24,
+				Attributes: []bgp.PathAttribute{
+					bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.Origin, Length: 1, Data: &bgp.OriginAttribute{Origin: bgp.IBGP}},
+					bgp.PathAttribute{Flags: bgp.Optional, Code: bgp.MPReachNLRI, Length: 17, Data: &bgp.MPReachNLRIAbbreviated{
+						NexthopLength: 16,
+						Nexthop:       bgp.MPNexthop{Global: net.ParseIP("2001:db8::2")},
+					}},
+				},
+			},
+		},
+	},
+}
+
+var marker = [16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// testMessageAS4 BGP4MP_MESSAGE_AS4 with UPDATE of 4-octet AS_PATH
+var testMessageAS4 = []byte{
+	0x5f, 0x5e, 0x10, 0x00, 0x00, 0x10, 0x00, 0x04, 0x00, 0x00, 0x00, 0x3c,
+	0x00, 0x00, 0xfd, 0xe9, 0x00, 0x00, 0xfd, 0xe8, 0x00, 0x00, 0x00, 0x01,
+	0xc0, 0xa8, 0x00, 0x02, 0xc0, 0xa8, 0x00, 0x01,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0x00, 0x28, 0x02, 0x00, 0x00, 0x00, 0x0d,
+	0x40, 0x01, 0x01, 0x00,
+	0x40, 0x02, 0x06, 0x02, 0x01, 0x00, 0x00, 0xfd, 0xe9,
+	0x18, 0x0a, 0x01, 0x02,
+}
+
+var messageAS4 = &Record{
+	Timestamp: 1600000000,
+	Type:      BGP4MP,
+	Subtype:   MessageAS4Subtype,
+	Length:    60,
+	Message: &Message{
+		Peer: Peer{
+			PeerAS:  65001,
+			LocalAS: 65000,
+			AFI:     bgp.IPv4,
+			PeerIP:  net.IP{0xc0, 0xa8, 0x00, 0x02},
+			LocalIP: net.IP{0xc0, 0xa8, 0x00, 0x01},
+		},
+		Message: bgp.Message{
+			Marker: marker,
+			Length: 40,
+			Type:   bgp.MessageType(2),
+			Body: &bgp.Update{
+				PathAttributeLength: 13,
+				PathAttributes: []bgp.PathAttribute{
+					bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.Origin, Length: 1, Data: &bgp.OriginAttribute{Origin: bgp.IBGP}},
+					bgp.PathAttribute{Flags: bgp.Transitive, Code: bgp.AsPath, Length: 6, Data: &[]bgp.AsPathAttribute{
+						bgp.AsPathAttribute{Type: bgp.AsSequence, Count: 1, List: []bgp.ASN4{65001}},
+					}},
+				},
+				NLRI: []bgp.PrefixSpec{bgp.PrefixSpec{Length: 24, Prefix: []byte{0x0a, 0x01, 0x02}}},
+			},
+		},
+	},
+}
+
+// testStateChangeET BGP4MP_ET STATE_CHANGE from OpenConfirm to Established
+var testStateChangeET = []byte{
+	0x5f, 0x5e, 0x10, 0x00, 0x00, 0x11, 0x00, 0x00, 0x00, 0x00, 0x00, 0x18,
+	0x00, 0x07, 0xa1, 0x20,
+	0xfd, 0xe9, 0xfd, 0xe8, 0x00, 0x00, 0x00, 0x01,
+	0xc0, 0xa8, 0x00, 0x02, 0xc0, 0xa8, 0x00, 0x01, 0x00, 0x05, 0x00, 0x06,
+}
+
+var stateChangeET = &Record{
+	Timestamp:    1600000000,
+	Type:         BGP4MPET,
+	Subtype:      StateChangeSubtype,
+	Length:       24,
+	Microseconds: 500000,
+	Message: &StateChange{
+		Peer: Peer{
+			PeerAS:  65001,
+			LocalAS: 65000,
+			AFI:     bgp.IPv4,
+			PeerIP:  net.IP{0xc0, 0xa8, 0x00, 0x02},
+			LocalIP: net.IP{0xc0, 0xa8, 0x00, 0x01},
+		},
+		OldState: OpenConfirm,
+		NewState: Established,
+	},
+}
+
+var testRecords = [][]byte{testPeerIndexTable, testRIBIPv4Unicast, testRIBIPv6Unicast, testMessageAS4, testStateChangeET}
+
+var records = []*Record{peerIndexTable, ribIPv4Unicast, ribIPv6Unicast, messageAS4, stateChangeET}
+
+func TestReader(t *testing.T) {
+	r := NewReader(bytes.NewReader(bytes.Join(testRecords, nil)))
+	for _, want := range records {
+		record, err := r.Next()
+		if !assert.NoError(t, err, "failed to read") {
+			return
+		}
+		assert.Empty(t, cmp.Diff(want, record), "diff found")
+	}
+	_, err := r.Next()
+	assert.Equal(t, io.EOF, err, "end of records")
+
+	r = NewReader(bytes.NewReader(testPeerIndexTable[:20]))
+	_, err = r.Next()
+	assert.Equal(t, io.ErrUnexpectedEOF, err, "partial record")
+
+	huge := append([]byte{}, testPeerIndexTable...)
+	huge[8], huge[9], huge[10], huge[11] = 0xff, 0xff, 0xff, 0xff
+	r = NewReader(bytes.NewReader(huge))
+	_, err = r.Next()
+	assert.Equal(t, &LengthError{Length: 0xffffffff}, err, "record over the max length")
+}
+
+func TestWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	w := NewWriter(buffer)
+	for _, record := range records {
+		r := *record
+		r.Length = 0
+		assert.NoError(t, w.Write(&r), "failed to write")
+		assert.Equal(t, uint32(0), r.Length, "record unchanged")
+	}
+	assert.Equal(t, bytes.Join(testRecords, nil), buffer.Bytes(), "same data")
+}
+
+func TestRecordSubtype(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	w := NewWriter(buffer)
+	assert.NoError(t, w.Write(&Record{Timestamp: 1600000000, Subtype: PeerIndexTableSubtype, Message: peerIndexTable.Message}))
+	assert.Equal(t, testPeerIndexTable, buffer.Bytes(), "type inferred")
+
+	// without the subtype, the message reads back as Raw
+	buffer.Reset()
+	assert.NoError(t, w.Write(&Record{Timestamp: 1600000000, Message: peerIndexTable.Message}))
+	record, err := NewReader(buffer).Next()
+	assert.NoError(t, err, "failed to read")
+	assert.Equal(t, TableDumpV2, record.Type)
+	assert.IsType(t, &packet.Raw{}, record.Message, "subtype not inferred")
+}
+
+func TestRecordTime(t *testing.T) {
+	assert.Equal(t, time.Unix(1600000000, 500000000), stateChangeET.Time())
+	assert.Equal(t, "Established", stateChangeET.Message.(*StateChange).NewState.String())
+}
+
+func TestRecordBGPSession(t *testing.T) {
+	s := ribIPv4Unicast.BGPSession()
+	s.Observe(&bgp.Message{Body: &bgp.Open{Version: 4, AS: 65000}})
+	assert.False(t, s.FourOctetAS, "4-octet AS not negotiated")
+	assert.Equal(t, &bgp.Session{FourOctetAS: true, AbbreviatedMPReach: true}, ribIPv4Unicast.BGPSession(), "new session")
+}
@@ -0,0 +1,86 @@
+package mrt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/nickchen/packet"
+)
+
+// MaxLength the maximum length of a record read by Reader, well above the RIB entries of a prefix or a BGP message,
+// so that a corrupted length doesn't allocate gigabytes
+const MaxLength = 1 << 24
+
+// LengthError describes a record with a length over MaxLength
+type LengthError struct {
+	Length uint32
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("mrt: record length %d exceeds %d", e.Length, MaxLength)
+}
+
+// Reader reads MRT records from a stream, such as the (decompressed) dumps from route collectors
+type Reader struct {
+	// Options for decoding the records, whose Session is used for BGP4MP messages with 2-octet AS
+	Options packet.UnmarshalOptions
+
+	r      *bufio.Reader
+	header [HeaderSize]byte
+}
+
+// NewReader returns a Reader reading from r
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Next reads the next record, it returns io.EOF when there are no more records, io.ErrUnexpectedEOF
+// when the stream ends in the middle of a record, and LengthError before reading a record over MaxLength
+func (r *Reader) Next() (*Record, error) {
+	if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(r.header[8:12])
+	if length > MaxLength {
+		return nil, &LengthError{Length: length}
+	}
+	b := make([]byte, HeaderSize+int(length))
+	copy(b, r.header[:])
+	if _, err := io.ReadFull(r.r, b[HeaderSize:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	record := &Record{}
+	if err := r.Options.Unmarshal(b, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Writer writes MRT records to a stream
+type Writer struct {
+	// Options for encoding the records, whose Session is used for BGP4MP messages with 2-octet AS
+	Options packet.MarshalOptions
+
+	w io.Writer
+}
+
+// NewWriter returns a Writer writing to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write encodes the record, the length being patched from the encoded message, while the record is left unchanged
+func (w *Writer) Write(record *Record) error {
+	b, err := w.Options.Marshal(record)
+	if err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(b[8:12], uint32(len(b)-HeaderSize))
+	_, err = w.w.Write(b)
+	return err
+}
@@ -0,0 +1,79 @@
+package mrt
+
+import (
+	"net"
+
+	"github.com/nickchen/packet/fixture/bgp"
+)
+
+// PeerIndexTable TABLE_DUMP_V2 PEER_INDEX_TABLE, https://tools.ietf.org/html/rfc6396#section-4.3.1
+type PeerIndexTable struct {
+	CollectorBGPID net.IP `packet:"length=4B"`
+	ViewNameLength uint16
+	ViewName       string `packet:"lengthfor"`
+	PeerCount      uint16
+	Peers          []PeerEntry `packet:"countfrom=PeerCount"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (t PeerIndexTable) LengthFor(fieldname string) uint64 {
+	return uint64(t.ViewNameLength)
+}
+
+// PeerType flags of the peer entry
+type PeerType uint8
+
+const (
+	// PeerIPv6 peer with IPv6 address
+	PeerIPv6 PeerType = 0x01
+	// PeerAS4 peer with 4-octet AS
+	PeerAS4 PeerType = 0x02
+)
+
+// PeerEntry peer of PEER_INDEX_TABLE, where the size of IP and AS are decided by Type
+type PeerEntry struct {
+	Type  PeerType
+	BGPID net.IP   `packet:"length=4B"`
+	IP    net.IP   `packet:"lengthfor"`
+	AS    bgp.ASN4 `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (p PeerEntry) LengthFor(fieldname string) uint64 {
+	switch fieldname {
+	case "IP":
+		if p.Type&PeerIPv6 != 0 {
+			return net.IPv6len
+		}
+		return net.IPv4len
+	case "AS":
+		if p.Type&PeerAS4 != 0 {
+			return 4
+		}
+		return 2
+	}
+	return 0
+}
+
+// RIB TABLE_DUMP_V2 RIB_IPV4_UNICAST, RIB_IPV4_MULTICAST, RIB_IPV6_UNICAST and RIB_IPV6_MULTICAST,
+// https://tools.ietf.org/html/rfc6396#section-4.3.2
+type RIB struct {
+	SequenceNumber uint32
+	Prefix         bgp.PrefixSpec
+	EntryCount     uint16
+	Entries        []RIBEntry `packet:"countfrom=EntryCount"`
+}
+
+// RIBEntry route of the prefix from the peer at PeerIndex of the PEER_INDEX_TABLE. The attributes always have
+// 4-octet AS, and the MP_REACH_NLRI is abbreviated to the next hop only, https://tools.ietf.org/html/rfc6396#section-4.3.4
+type RIBEntry struct {
+	PeerIndex       uint16
+	OriginatedTime  uint32
+	AttributeLength uint16
+	Attributes      []bgp.PathAttribute `packet:"lengthfor"`
+}
+
+// LengthFor implementation of the LengthFor interface, which returns length in bytes for the provided field
+func (e RIBEntry) LengthFor(fieldname string) uint64 {
+	return uint64(e.AttributeLength)
+}
@@ -1,37 +1,242 @@
 package fixture
 
 import (
-	"github.com/google/gopacket"
-	"github.com/google/gopacket/pcap"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nickchen/packet"
+	"github.com/nickchen/packet/fixture/pcap"
 )
 
 // PacketData raw bytes for the packet
 type PacketData []byte
 
-// Pcap helps with reading packet from pcap format
+// CapturedPacket packet data along with the capture metadata
+type CapturedPacket struct {
+	Timestamp time.Time
+	// CaptureLength of the Data, shorter than Length when the packet is truncated by the snapshot length
+	CaptureLength int
+	// Length of the packet on the wire
+	Length int
+	// InterfaceIndex of the pcapng interface, always zero for pcap
+	InterfaceIndex int
+	LinkType       LinkType
+	Data           PacketData
+}
+
+// TruncatedError describes a packet cut short by the snapshot length of the capture, along with the error of
+// decoding the short packet if any
+type TruncatedError struct {
+	CaptureLength int
+	Length        int
+	Err           error
+}
+
+func (e *TruncatedError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("fixture: packet truncated to %d of %d bytes: %s", e.CaptureLength, e.Length, e.Err)
+	}
+	return fmt.Sprintf("fixture: packet truncated to %d of %d bytes", e.CaptureLength, e.Length)
+}
+
+// Truncated returns TruncatedError when the packet is cut short by the snapshot length, nil otherwise
+func (p *CapturedPacket) Truncated() error {
+	if p.CaptureLength < p.Length {
+		return &TruncatedError{CaptureLength: p.CaptureLength, Length: p.Length}
+	}
+	return nil
+}
+
+// Decode decodes the data by the link type with DecodeLinkLayer. When the packet is truncated, the error of
+// decoding is returned as TruncatedError, since it's the short packet rather than a malformed one.
+func (p *CapturedPacket) Decode() (interface{}, error) {
+	v, err := DecodeLinkLayer(p.LinkType, p.Data)
+	if err != nil && p.CaptureLength < p.Length {
+		if _, ok := err.(*LinkTypeError); !ok {
+			return v, &TruncatedError{CaptureLength: p.CaptureLength, Length: p.Length, Err: err}
+		}
+	}
+	return v, err
+}
+
+// Pcap helps with reading packet from pcap or pcapng format, either with Next or the channel of Packets
 type Pcap struct {
-	source  *gopacket.PacketSource
-	channel chan PacketData
+	file    *os.File
+	reader  *pcap.Reader
+	packet  *CapturedPacket
+	err     error
+	packets chan *CapturedPacket
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
 }
 
-// OpenPCAP read file as gopacket.PacketSource
+// OpenPCAP opens the pcap or pcapng file, without libpcap, to be closed with Close
 func OpenPCAP(file string) (*Pcap, error) {
-	handle, err := pcap.OpenOffline(file)
+	f, err := os.Open(file)
 	if err != nil {
 		return nil, err
 	}
-	return &Pcap{source: gopacket.NewPacketSource(handle, handle.LinkType())}, nil
+	reader, err := pcap.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Pcap{file: f, reader: reader, done: make(chan struct{})}, nil
+}
+
+// LinkType returns the link type of the file, or of the first interface for pcapng, to be used with DecodeLinkLayer
+func (p *Pcap) LinkType() LinkType {
+	return LinkType(p.reader.LinkType())
+}
+
+// NextPacket reads the next packet along with the capture metadata, it returns io.EOF when there are no more
+// packets. It's not to be mixed with Next or Packets.
+func (p *Pcap) NextPacket() (*CapturedPacket, error) {
+	next, err := p.reader.Next()
+	if err != nil {
+		return nil, err
+	}
+	return &CapturedPacket{
+		Timestamp:      next.Timestamp,
+		CaptureLength:  next.CaptureLength,
+		Length:         next.Length,
+		InterfaceIndex: next.InterfaceIndex,
+		LinkType:       LinkType(next.LinkType),
+		Data:           PacketData(next.Data),
+	}, nil
+}
+
+// Next advances to the next packet, available with Packet. It returns false at the end of the capture or on
+// error, which is reported by Err.
+func (p *Pcap) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	p.packet, p.err = p.NextPacket()
+	return p.err == nil
+}
+
+// Packet returns the packet read by Next
+func (p *Pcap) Packet() *CapturedPacket {
+	return p.packet
+}
+
+// Err returns the error that stopped Next or Packets, nil at the end of the capture
+func (p *Pcap) Err() error {
+	if p.err == io.EOF {
+		return nil
+	}
+	return p.err
 }
 
-// PacketData use channel so it's possible to do range
-func (p *Pcap) PacketData() chan PacketData {
-	if p.channel == nil {
-		p.channel = make(chan PacketData, 5)
-		go func() {
-			for gp := range p.source.Packets() {
-				p.channel <- PacketData(gp.Data())
+// Packets returns a channel of the packets, which is closed at the end of the capture, on error, when the
+// context is done or on Close, Err being checked once it's closed. The channel is created by the first call,
+// and it's not to be mixed with Next.
+func (p *Pcap) Packets(ctx context.Context) <-chan *CapturedPacket {
+	if p.packets != nil {
+		return p.packets
+	}
+	p.packets = make(chan *CapturedPacket, 5)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer close(p.packets)
+		for {
+			select {
+			case <-ctx.Done():
+				p.err = ctx.Err()
+				return
+			case <-p.done:
+				return
+			default:
+			}
+			if !p.Next() {
+				return
+			}
+			select {
+			case p.packets <- p.packet:
+			case <-ctx.Done():
+				p.err = ctx.Err()
+				return
+			case <-p.done:
+				return
 			}
-		}()
+		}
+	}()
+	return p.packets
+}
+
+// Close stops the goroutine of Packets, waiting for it to exit, and closes the file
+func (p *Pcap) Close() error {
+	err := os.ErrClosed
+	p.once.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+		err = p.file.Close()
+	})
+	return err
+}
+
+// PcapWriter writes packets to a pcap or pcapng file, e.g. the synthesized structs of a failed test to be inspected
+// with Wireshark
+type PcapWriter struct {
+	writer *pcap.Writer
+	file   *os.File
+}
+
+// CreatePCAP creates the file of the link type, in pcapng format when the name ends with .pcapng, to be closed
+// with Close
+func CreatePCAP(file string, linkType LinkType) (*PcapWriter, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	w := NewPcapWriter(f, linkType, strings.HasSuffix(file, ".pcapng"))
+	w.file = f
+	return w, nil
+}
+
+// NewPcapWriter returns a PcapWriter writing pcap, or pcapng when ng is true, to w
+func NewPcapWriter(w io.Writer, linkType LinkType, ng bool) *PcapWriter {
+	if ng {
+		return &PcapWriter{writer: pcap.NewNgWriter(w, uint32(linkType))}
+	}
+	return &PcapWriter{writer: pcap.NewWriter(w, uint32(linkType))}
+}
+
+// Write marshals the struct, e.g. EthernetII, and writes it with the timestamp, the time of writing when the
+// timestamp is zero
+func (w *PcapWriter) Write(timestamp time.Time, v interface{}) error {
+	b, err := packet.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.WritePacket(&CapturedPacket{Timestamp: timestamp, Data: b})
+}
+
+// WritePacket writes the data of the packet with its Timestamp and Length, e.g. a packet read by Pcap, the time
+// of writing being used when the Timestamp is zero
+func (w *PcapWriter) WritePacket(p *CapturedPacket) error {
+	timestamp := p.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return w.writer.WritePacket(&pcap.Packet{Timestamp: timestamp, Length: p.Length, Data: p.Data})
+}
+
+// Close writes the header when no packets have been written, and closes the file created by CreatePCAP
+func (w *PcapWriter) Close() error {
+	err := w.writer.WriteHeader()
+	if w.file != nil {
+		if cerr := w.file.Close(); err == nil {
+			err = cerr
+		}
 	}
-	return p.channel
+	return err
 }
@@ -0,0 +1,92 @@
+// Package pcap reads and writes packet captures without libpcap, in the libpcap file format
+// (https://tools.ietf.org/html/draft-gharris-opsawg-pcap) and the pcapng format
+// (https://tools.ietf.org/html/draft-tuexen-opsawg-pcapng), the headers and blocks being decoded by package packet
+// in the byte order of the file.
+package pcap
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// Magic numbers of the pcap file header, in the byte order of the file
+const (
+	// MagicMicroseconds timestamps in seconds and microseconds
+	MagicMicroseconds uint32 = 0xa1b2c3d4
+	// MagicNanoseconds timestamps in seconds and nanoseconds
+	MagicNanoseconds uint32 = 0xa1b23c4d
+)
+
+// Version of the pcap file format
+const (
+	VersionMajor uint16 = 2
+	VersionMinor uint16 = 4
+)
+
+// DefaultSnapLen the snapshot length written when it's not specified, as tcpdump does
+const DefaultSnapLen uint32 = 262144
+
+// HeaderSize the size of the pcap file header
+const HeaderSize = 24
+
+// RecordHeaderSize the size of the pcap record header preceding the packet data
+const RecordHeaderSize = 16
+
+// MaxCaptureLength the maximum capture length of a record read by Reader, so that a corrupted length doesn't allocate
+// gigabytes. The snapshot length of the file doesn't bound the records, as some tools write a wrong one.
+const MaxCaptureLength uint32 = 1 << 24
+
+// Header pcap file header
+type Header struct {
+	Magic        uint32
+	VersionMajor uint16
+	VersionMinor uint16
+	// ThisZone GMT offset in seconds, always zero in practice
+	ThisZone uint32
+	SigFigs  uint32
+	SnapLen  uint32
+	LinkType uint32
+}
+
+// Record pcap record, the packet data along with its header
+type Record struct {
+	Seconds uint32
+	// Fraction of the second in microseconds or nanoseconds, depending on the Magic of the file
+	Fraction uint32
+	// CaptureLength of the Data, which is shorter than Length when the packet is truncated by the SnapLen
+	CaptureLength uint32
+	Length        uint32
+	Data          []byte `packet:"lengthfor"`
+}
+
+// LengthFor returns the length in bytes for the provided field
+func (r Record) LengthFor(fieldname string) uint64 {
+	return uint64(r.CaptureLength)
+}
+
+// Packet packet data with the capture metadata, from either format
+type Packet struct {
+	Timestamp time.Time
+	// CaptureLength of the Data
+	CaptureLength int
+	// Length of the packet on the wire
+	Length int
+	// InterfaceIndex of the pcapng interface, always zero for pcap
+	InterfaceIndex int
+	LinkType       uint32
+	Data           []byte
+}
+
+// byteOrderOf returns the byte order in which b holds one of the magics along with the magic, or nil when it holds none
+func byteOrderOf(b []byte, magics ...uint32) (binary.ByteOrder, uint32) {
+	be, le := binary.BigEndian.Uint32(b), binary.LittleEndian.Uint32(b)
+	for _, m := range magics {
+		switch m {
+		case be:
+			return binary.BigEndian, m
+		case le:
+			return binary.LittleEndian, m
+		}
+	}
+	return nil, 0
+}
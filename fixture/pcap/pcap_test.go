@@ -0,0 +1,236 @@
+package pcap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPackets = []*Packet{
+	&Packet{Timestamp: time.Unix(1600000000, 123456789), Length: 6, Data: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}},
+	&Packet{Timestamp: time.Unix(1600000001, 1000), Length: 1500, Data: []byte{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, 0x00, 0x11}},
+}
+
+// expected returns the packets as read back, with the timestamps in the resolution
+func expected(resolution Resolution, linkType uint32) []*Packet {
+	packets := []*Packet{}
+	for _, p := range testPackets {
+		t := p.Timestamp
+		if resolution == Microseconds {
+			t = t.Truncate(time.Microsecond)
+		}
+		packets = append(packets, &Packet{Timestamp: t, CaptureLength: len(p.Data), Length: p.Length, LinkType: linkType, Data: p.Data})
+	}
+	return packets
+}
+
+func readAll(t *testing.T, r *Reader) []*Packet {
+	packets := []*Packet{}
+	for {
+		p, err := r.Next()
+		if err == io.EOF {
+			return packets
+		}
+		if !assert.NoError(t, err) {
+			return packets
+		}
+		packets = append(packets, p)
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		ng         bool
+		order      binary.ByteOrder
+		resolution Resolution
+	}{
+		{name: "pcap", order: binary.BigEndian, resolution: Microseconds},
+		{name: "pcap little endian", order: binary.LittleEndian, resolution: Microseconds},
+		{name: "pcap nanoseconds", order: binary.LittleEndian, resolution: Nanoseconds},
+		{name: "pcapng", ng: true, order: binary.BigEndian, resolution: Microseconds},
+		{name: "pcapng little endian", ng: true, order: binary.LittleEndian, resolution: Microseconds},
+		{name: "pcapng nanoseconds", ng: true, order: binary.BigEndian, resolution: Nanoseconds},
+	} {
+		buf := &bytes.Buffer{}
+		w := NewWriter(buf, 1)
+		if test.ng {
+			w = NewNgWriter(buf, 1)
+		}
+		w.Options.ByteOrder, w.Resolution = test.order, test.resolution
+		for _, p := range testPackets {
+			assert.NoError(t, w.WritePacket(p), test.name)
+		}
+		b := buf.Bytes()
+
+		r, err := NewReader(bytes.NewReader(b))
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		assert.Equal(t, test.ng, r.NG(), test.name)
+		assert.Equal(t, test.order, r.ByteOrder(), test.name)
+		assert.Equal(t, []Interface{Interface{LinkType: 1, SnapLen: DefaultSnapLen, Resolution: test.resolution}}, r.Interfaces(), test.name)
+		assert.Equal(t, expected(test.resolution, 1), readAll(t, r), test.name)
+
+		// cross-check with gopacket
+		var source gopacket.PacketDataSource
+		if test.ng {
+			source, err = pcapgo.NewNgReader(bytes.NewReader(b), pcapgo.DefaultNgReaderOptions)
+		} else {
+			source, err = pcapgo.NewReader(bytes.NewReader(b))
+		}
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		for _, p := range expected(test.resolution, 1) {
+			data, ci, err := source.ReadPacketData()
+			assert.NoError(t, err, test.name)
+			assert.Equal(t, p.Data, data, test.name)
+			assert.Equal(t, p.Length, ci.Length, test.name)
+			assert.True(t, p.Timestamp.Equal(ci.Timestamp), test.name)
+		}
+
+		// truncated in the middle of the last packet
+		r, _ = NewReader(bytes.NewReader(b[:len(b)-5]))
+		_, err = r.Next()
+		assert.NoError(t, err, test.name)
+		_, err = r.Next()
+		assert.Equal(t, io.ErrUnexpectedEOF, err, test.name)
+	}
+}
+
+func TestReadGopacket(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := pcapgo.NewWriter(buf)
+	assert.NoError(t, w.WriteFileHeader(65535, layers.LinkTypeEthernet))
+	for _, p := range testPackets {
+		assert.NoError(t, w.WritePacket(gopacket.CaptureInfo{Timestamp: p.Timestamp, CaptureLength: len(p.Data), Length: p.Length}, p.Data))
+	}
+	r, err := NewReader(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, Header{Magic: MagicMicroseconds, VersionMajor: 2, VersionMinor: 4, SnapLen: 65535, LinkType: 1}, r.Header())
+	assert.Equal(t, expected(Microseconds, 1), readAll(t, r))
+
+	// the section header has options, and the interface description has the name and if_tsresol of nanoseconds
+	buf.Reset()
+	ngw, err := pcapgo.NewNgWriterInterface(buf, pcapgo.NgInterface{Name: "eth0", LinkType: layers.LinkTypeLinuxSLL, SnapLength: 65535, TimestampResolution: 9},
+		pcapgo.NgWriterOptions{SectionInfo: pcapgo.NgSectionInfo{Hardware: "amd64", OS: "linux", Application: "test"}})
+	assert.NoError(t, err)
+	for _, p := range testPackets {
+		assert.NoError(t, ngw.WritePacket(gopacket.CaptureInfo{Timestamp: p.Timestamp, CaptureLength: len(p.Data), Length: p.Length}, p.Data))
+	}
+	assert.NoError(t, ngw.Flush())
+	r, err = NewReader(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, []Interface{Interface{LinkType: 113, SnapLen: 65535, Resolution: Nanoseconds}}, r.Interfaces())
+	assert.Equal(t, expected(Nanoseconds, 113), readAll(t, r))
+}
+
+// testSection little endian section with a comment, an interface of Ethernet in 2^-10 seconds, a simple packet
+// and an enhanced packet with a comment
+var testSection = []byte{
+	0x0a, 0x0d, 0x0d, 0x0a, 0x24, 0x00, 0x00, 0x00, 0x4d, 0x3c, 0x2b, 0x1a, 0x01, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x00, 0x02, 0x00, 0x68, 0x69, 0x00, 0x00,
+	0x24, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00,
+	0x09, 0x00, 0x01, 0x00, 0x8a, 0x00, 0x00, 0x00, 0x1c, 0x00, 0x00, 0x00,
+	0x03, 0x00, 0x00, 0x00, 0x18, 0x00, 0x00, 0x00, 0x06, 0x00, 0x00, 0x00, 0x01, 0x02, 0x03, 0x04,
+	0x05, 0x06, 0x00, 0x00, 0x18, 0x00, 0x00, 0x00,
+	0x06, 0x00, 0x00, 0x00, 0x30, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x06, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0xaa, 0xbb, 0xcc, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x21, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x30, 0x00, 0x00, 0x00,
+}
+
+func TestReadSection(t *testing.T) {
+	r, err := NewReader(bytes.NewReader(testSection))
+	assert.NoError(t, err)
+	assert.Equal(t, binary.ByteOrder(binary.LittleEndian), r.ByteOrder())
+	assert.Equal(t, []Interface{Interface{LinkType: 1, SnapLen: 4, Resolution: 0x8a}}, r.Interfaces())
+	assert.Equal(t, []*Packet{
+		&Packet{CaptureLength: 4, Length: 6, LinkType: 1, Data: []byte{0x01, 0x02, 0x03, 0x04}},
+		&Packet{Timestamp: time.Unix(1, 500000000), CaptureLength: 3, Length: 3, LinkType: 1, Data: []byte{0xaa, 0xbb, 0xcc}},
+	}, readAll(t, r))
+
+	block := &Block{}
+	assert.NoError(t, r.options.Unmarshal(testSection[:36], block))
+	assert.Equal(t, &Block{Type: BlockSectionHeader, Length: 36, TrailingLength: 36, Body: &SectionHeader{
+		ByteOrderMagic: ByteOrderMagic,
+		VersionMajor:   1,
+		SectionLength:  SectionLengthUnspecified,
+		Options:        []Option{Option{Code: OptionComment, Length: 2, Value: []byte("hi")}},
+	}}, block)
+
+	// the options of the enhanced packet end with opt_endofopt
+	block = &Block{}
+	assert.NoError(t, r.options.Unmarshal(testSection[88:], block))
+	if epb, ok := block.Body.(*EnhancedPacket); assert.True(t, ok, "enhanced packet") {
+		assert.Equal(t, []Option{Option{Code: OptionComment, Length: 1, Value: []byte("!")}, Option{Code: OptionEnd}}, epb.Options)
+	}
+
+	_, err = NewReader(bytes.NewReader([]byte{0x01, 0x02, 0x03, 0x04}))
+	assert.Equal(t, &MagicError{Magic: 0x01020304}, err)
+}
+
+func TestOptionEnd(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewNgWriter(&buf, 1)
+	w.Resolution = Nanoseconds
+	assert.NoError(t, w.WriteHeader())
+	b := buf.Bytes()
+	b = b[binary.BigEndian.Uint32(b[4:8]):]
+	assert.Equal(t, 32, len(b), "if_tsresol padded to 4 bytes and opt_endofopt of 4 bytes")
+
+	block := &Block{}
+	assert.NoError(t, packet.Unmarshal(b, block))
+	if idb, ok := block.Body.(*InterfaceDescription); assert.True(t, ok, "interface description") {
+		assert.Equal(t, []Option{Option{Code: OptionTsResol, Length: 1, Value: []byte{9}}, Option{Code: OptionEnd}}, idb.Options)
+	}
+}
+
+func TestReadLength(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1)
+	for _, p := range testPackets {
+		assert.NoError(t, w.WritePacket(p))
+	}
+	b := buf.Bytes()
+
+	// records over the snapshot length are read as a whole
+	binary.BigEndian.PutUint32(b[16:], 4)
+	r, err := NewReader(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, expected(Microseconds, 1), readAll(t, r))
+
+	binary.BigEndian.PutUint32(b[HeaderSize+8:], MaxCaptureLength+1)
+	r, err = NewReader(bytes.NewReader(b))
+	assert.NoError(t, err)
+	_, err = r.Next()
+	assert.Equal(t, &RecordLengthError{CaptureLength: MaxCaptureLength + 1}, err)
+
+	section := append([]byte{}, testSection...)
+	binary.LittleEndian.PutUint32(section[92:], 0xfffffff0)
+	r, err = NewReader(bytes.NewReader(section))
+	assert.NoError(t, err)
+	_, err = r.Next()
+	assert.NoError(t, err, "simple packet before")
+	_, err = r.Next()
+	assert.Equal(t, &BlockLengthError{Type: BlockEnhancedPacket, Length: 0xfffffff0}, err)
+
+	// the trailing length differs
+	section = append([]byte{}, testSection...)
+	binary.LittleEndian.PutUint32(section[132:], 0x2c)
+	r, err = NewReader(bytes.NewReader(section))
+	assert.NoError(t, err)
+	_, err = r.Next()
+	assert.NoError(t, err, "simple packet before")
+	_, err = r.Next()
+	assert.Equal(t, &BlockLengthError{Type: BlockEnhancedPacket, Length: 0x2c}, err)
+}
@@ -0,0 +1,254 @@
+package pcap
+
+import (
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/nickchen/packet"
+)
+
+// BlockType type of pcapng block
+type BlockType uint32
+
+const (
+	BlockInterfaceDescription BlockType = 0x00000001
+	BlockSimplePacket         BlockType = 0x00000003
+	BlockNameResolution       BlockType = 0x00000004
+	BlockInterfaceStatistics  BlockType = 0x00000005
+	BlockEnhancedPacket       BlockType = 0x00000006
+	// BlockSectionHeader the same in either byte order, the byte order being given by the ByteOrderMagic
+	BlockSectionHeader BlockType = 0x0a0d0d0a
+)
+
+func (t BlockType) String() string {
+	switch t {
+	case BlockInterfaceDescription:
+		return "IDB"
+	case BlockSimplePacket:
+		return "SPB"
+	case BlockNameResolution:
+		return "NRB"
+	case BlockInterfaceStatistics:
+		return "ISB"
+	case BlockEnhancedPacket:
+		return "EPB"
+	case BlockSectionHeader:
+		return "SHB"
+	}
+	return fmt.Sprintf("BlockType(0x%x)", uint32(t))
+}
+
+// ByteOrderMagic of the section header, in the byte order of the section
+const ByteOrderMagic uint32 = 0x1a2b3c4d
+
+// SectionLengthUnspecified section length when the section is not skipped over
+const SectionLengthUnspecified uint64 = 0xffffffffffffffff
+
+// BlockHeaderSize the size of the block type and length, the length being repeated after the body
+const BlockHeaderSize = 8
+
+// MaxBlockLength the maximum length of a block read by Reader, so that a corrupted length doesn't allocate gigabytes
+const MaxBlockLength uint32 = 1 << 24
+
+// Block pcapng block, Length is the total length including the type and both lengths, the body being padded to
+// 4 bytes. Blocks other than the section header, interface description and packets are kept as Raw.
+type Block struct {
+	Type           BlockType
+	Length         uint32
+	Body           interface{} `packet:"lengthfor"`
+	TrailingLength uint32
+}
+
+// LengthFor returns the length in bytes for the provided field
+func (b Block) LengthFor(fieldname string) uint64 {
+	if b.Length < 12 {
+		return 0
+	}
+	return uint64(b.Length) - 12
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (b Block) InstanceFor(fieldname string) interface{} {
+	switch b.Type {
+	case BlockSectionHeader:
+		return &SectionHeader{}
+	case BlockInterfaceDescription:
+		return &InterfaceDescription{}
+	case BlockSimplePacket:
+		return &SimplePacket{}
+	case BlockEnhancedPacket:
+		return &EnhancedPacket{}
+	}
+	return nil
+}
+
+// InstanceType returns the Type for the Body struct pointer
+func (b Block) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	switch body.(type) {
+	case *SectionHeader:
+		return "Type", uint64(BlockSectionHeader)
+	case *InterfaceDescription:
+		return "Type", uint64(BlockInterfaceDescription)
+	case *SimplePacket:
+		return "Type", uint64(BlockSimplePacket)
+	case *EnhancedPacket:
+		return "Type", uint64(BlockEnhancedPacket)
+	}
+	return "", 0
+}
+
+// SectionHeader section header block, starting a section with its own byte order and interfaces
+type SectionHeader struct {
+	ByteOrderMagic uint32
+	VersionMajor   uint16
+	VersionMinor   uint16
+	SectionLength  uint64
+	Options        []Option
+}
+
+// InterfaceDescription interface description block, the interfaces are numbered from zero within the section
+type InterfaceDescription struct {
+	LinkType uint16
+	Reserved uint16
+	SnapLen  uint32
+	Options  []Option
+}
+
+// Resolution returns the resolution of the timestamps from the if_tsresol option, microseconds by default
+func (i InterfaceDescription) Resolution() Resolution {
+	if o := findOption(i.Options, OptionTsResol); o != nil && len(o.Value) == 1 {
+		return Resolution(o.Value[0])
+	}
+	return Microseconds
+}
+
+// EnhancedPacket enhanced packet block, the timestamp being in the resolution of the interface
+type EnhancedPacket struct {
+	InterfaceID   uint32
+	TimestampHigh uint32
+	TimestampLow  uint32
+	CaptureLength uint32
+	Length        uint32
+	Data          []byte `packet:"lengthfor"`
+	Padding       []byte `packet:"lengthfor"`
+	Options       []Option
+}
+
+// LengthFor returns the length in bytes for the provided field, the Data being padded to 4 bytes
+func (p EnhancedPacket) LengthFor(fieldname string) uint64 {
+	switch fieldname {
+	case "Data":
+		return uint64(p.CaptureLength)
+	case "Padding":
+		return uint64(-p.CaptureLength & 3)
+	}
+	return 0
+}
+
+// Timestamp returns the timestamp in units of the resolution
+func (p EnhancedPacket) Timestamp() uint64 {
+	return uint64(p.TimestampHigh)<<32 | uint64(p.TimestampLow)
+}
+
+// SimplePacket simple packet block of the first interface, the Data is padded to 4 bytes and truncated by the
+// SnapLen of the interface
+type SimplePacket struct {
+	Length uint32
+	Data   []byte
+}
+
+// OptionCode code of pcapng option, the meaning of the codes other than the end and the comment depends on the block
+type OptionCode uint16
+
+const (
+	OptionEnd     OptionCode = 0
+	OptionComment OptionCode = 1
+	// section header options
+	OptionHardware OptionCode = 2
+	OptionOS       OptionCode = 3
+	OptionUserAppl OptionCode = 4
+	// interface description options
+	OptionIfName     OptionCode = 2
+	OptionIfDesc     OptionCode = 3
+	OptionTsResol    OptionCode = 9
+	OptionTsOffset   OptionCode = 14
+	OptionIfHardware OptionCode = 15
+	// enhanced packet options
+	OptionFlags OptionCode = 2
+)
+
+// Option pcapng option, the Value is padded to 4 bytes, and the options end with OptionEnd of length zero, which is
+// optional when the options fill the block
+type Option struct {
+	Code   OptionCode `packet:"tlv=type"`
+	Length uint16     `packet:"tlv=length"`
+	Value  []byte     `packet:"tlv=value"`
+}
+
+var optionSpec = &packet.TLVSpec{
+	End:   []uint64{uint64(OptionEnd)},
+	Align: 4,
+}
+
+// TLV returns the spec of pcapng options
+func (o Option) TLV() *packet.TLVSpec {
+	return optionSpec
+}
+
+func findOption(options []Option, code OptionCode) *Option {
+	for i := range options {
+		if options[i].Code == code {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// Resolution resolution of the timestamps as the if_tsresol option, a negative power of 10, or a negative power
+// of 2 when the most significant bit is set
+type Resolution uint8
+
+const (
+	Microseconds Resolution = 6
+	Nanoseconds  Resolution = 9
+)
+
+// unitsPerSecond returns the units of the resolution in a second, false when it doesn't fit in 64 bits
+func (r Resolution) unitsPerSecond() (uint64, bool) {
+	if r&0x80 != 0 {
+		exp := uint(r & 0x7f)
+		return 1 << exp, exp < 64
+	}
+	units := uint64(1)
+	for i := Resolution(0); i < r; i++ {
+		hi, lo := bits.Mul64(units, 10)
+		if hi != 0 {
+			return 0, false
+		}
+		units = lo
+	}
+	return units, true
+}
+
+// Time returns the time of the timestamp in units of the resolution, since the epoch
+func (r Resolution) Time(timestamp uint64) time.Time {
+	units, ok := r.unitsPerSecond()
+	if !ok {
+		return time.Unix(0, 0)
+	}
+	hi, lo := bits.Mul64(timestamp%units, uint64(time.Second))
+	nanoseconds, _ := bits.Div64(hi, lo, units)
+	return time.Unix(int64(timestamp/units), int64(nanoseconds))
+}
+
+// Timestamp returns the timestamp of the time in units of the resolution, since the epoch
+func (r Resolution) Timestamp(t time.Time) uint64 {
+	units, ok := r.unitsPerSecond()
+	if !ok {
+		return 0
+	}
+	hi, lo := bits.Mul64(uint64(t.Nanosecond()), units)
+	fraction, _ := bits.Div64(hi, lo, uint64(time.Second))
+	return uint64(t.Unix())*units + fraction
+}
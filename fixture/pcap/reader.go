@@ -0,0 +1,280 @@
+package pcap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nickchen/packet"
+)
+
+// MagicError describes a stream starting with neither the pcap magic nor the pcapng section header
+type MagicError struct {
+	Magic uint32
+}
+
+func (e *MagicError) Error() string {
+	return fmt.Sprintf("pcap: unknown magic 0x%08x", e.Magic)
+}
+
+// RecordLengthError describes a pcap record with a capture length over MaxCaptureLength
+type RecordLengthError struct {
+	CaptureLength uint32
+}
+
+func (e *RecordLengthError) Error() string {
+	return fmt.Sprintf("pcap: capture length %d of record exceeds %d", e.CaptureLength, MaxCaptureLength)
+}
+
+// BlockLengthError describes a pcapng block with a length too short, not a multiple of 4, over MaxBlockLength, or
+// a trailing length different from the length
+type BlockLengthError struct {
+	Type   BlockType
+	Length uint32
+}
+
+func (e *BlockLengthError) Error() string {
+	return fmt.Sprintf("pcap: invalid length %d of block %s", e.Length, e.Type)
+}
+
+// InterfaceError describes a packet of an interface not described in the section
+type InterfaceError struct {
+	InterfaceID uint32
+	Interfaces  int
+}
+
+func (e *InterfaceError) Error() string {
+	return fmt.Sprintf("pcap: packet of interface %d, but only %d described", e.InterfaceID, e.Interfaces)
+}
+
+// Interface capture interface of the packets, pcap files having a single one described by the file header
+type Interface struct {
+	LinkType   uint32
+	SnapLen    uint32
+	Resolution Resolution
+	// Offset in seconds added to the timestamps, from the if_tsoffset option
+	Offset int64
+}
+
+// Reader reads packets from a pcap or pcapng stream, the format and the byte order being detected from the
+// magic. The byte order of pcapng can change with each section.
+type Reader struct {
+	ng         bool
+	options    packet.UnmarshalOptions
+	header     Header
+	interfaces []Interface
+	r          *bufio.Reader
+}
+
+// NewReader returns a Reader reading from r, after reading the pcap file header, or the pcapng section header
+// along with the interface descriptions following it
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r)}
+	b, err := reader.r.Peek(4)
+	if err != nil {
+		return nil, err
+	}
+	if BlockType(binary.BigEndian.Uint32(b)) == BlockSectionHeader {
+		reader.ng = true
+		if _, err := reader.nextBlock(); err != nil {
+			return nil, err
+		}
+		// the interfaces are described before the packets
+		for {
+			b, err := reader.r.Peek(4)
+			if err != nil || BlockType(reader.options.ByteOrder.Uint32(b)) != BlockInterfaceDescription {
+				break
+			}
+			if _, err := reader.nextBlock(); err != nil {
+				return nil, err
+			}
+		}
+		return reader, nil
+	}
+	order, magic := byteOrderOf(b, MagicMicroseconds, MagicNanoseconds)
+	if order == nil {
+		return nil, &MagicError{Magic: binary.BigEndian.Uint32(b)}
+	}
+	reader.options.ByteOrder = order
+	var header [HeaderSize]byte
+	if _, err := io.ReadFull(reader.r, header[:]); err != nil {
+		return nil, unexpected(err)
+	}
+	if err := reader.options.Unmarshal(header[:], &reader.header); err != nil {
+		return nil, err
+	}
+	resolution := Microseconds
+	if magic == MagicNanoseconds {
+		resolution = Nanoseconds
+	}
+	reader.interfaces = []Interface{Interface{LinkType: reader.header.LinkType, SnapLen: reader.header.SnapLen, Resolution: resolution}}
+	return reader, nil
+}
+
+// NG returns true when the stream is pcapng
+func (r *Reader) NG() bool {
+	return r.ng
+}
+
+// ByteOrder returns the byte order of the file, or of the current section for pcapng
+func (r *Reader) ByteOrder() binary.ByteOrder {
+	return r.options.ByteOrder
+}
+
+// Header returns the file header of pcap, which is zero for pcapng
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Interfaces returns the interfaces described so far, in the current section for pcapng
+func (r *Reader) Interfaces() []Interface {
+	return r.interfaces
+}
+
+// LinkType returns the link type of the first interface, which is the link type of all the packets for pcap
+func (r *Reader) LinkType() uint32 {
+	if len(r.interfaces) == 0 {
+		return 0
+	}
+	return r.interfaces[0].LinkType
+}
+
+// Next reads the next packet, skipping the blocks other than packets. It returns io.EOF when there are no more
+// packets, and io.ErrUnexpectedEOF when the stream ends in the middle of a record or a block.
+func (r *Reader) Next() (*Packet, error) {
+	if !r.ng {
+		return r.nextRecord()
+	}
+	for {
+		block, err := r.nextBlock()
+		if err != nil {
+			return nil, err
+		}
+		switch body := block.Body.(type) {
+		case *EnhancedPacket:
+			return r.packet(body.InterfaceID, body.Timestamp(), body.CaptureLength, body.Length, body.Data)
+		case *SimplePacket:
+			data := body.Data
+			if uint32(len(data)) > body.Length {
+				data = data[:body.Length]
+			}
+			if len(r.interfaces) > 0 && r.interfaces[0].SnapLen != 0 && uint32(len(data)) > r.interfaces[0].SnapLen {
+				data = data[:r.interfaces[0].SnapLen]
+			}
+			p, err := r.packet(0, 0, uint32(len(data)), body.Length, data)
+			if err != nil {
+				return nil, err
+			}
+			// simple packets have no timestamp
+			p.Timestamp = time.Time{}
+			return p, nil
+		}
+	}
+}
+
+func (r *Reader) nextRecord() (*Packet, error) {
+	var header [RecordHeaderSize]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		return nil, err
+	}
+	captureLength := r.options.ByteOrder.Uint32(header[8:12])
+	if captureLength > MaxCaptureLength {
+		return nil, &RecordLengthError{CaptureLength: captureLength}
+	}
+	b := make([]byte, RecordHeaderSize+int(captureLength))
+	copy(b, header[:])
+	if _, err := io.ReadFull(r.r, b[RecordHeaderSize:]); err != nil {
+		return nil, unexpected(err)
+	}
+	record := &Record{}
+	if err := r.options.Unmarshal(b, record); err != nil {
+		return nil, err
+	}
+	fraction := time.Duration(record.Fraction) * time.Microsecond
+	if r.interfaces[0].Resolution == Nanoseconds {
+		fraction = time.Duration(record.Fraction)
+	}
+	return &Packet{
+		Timestamp:     time.Unix(int64(record.Seconds), int64(fraction)),
+		CaptureLength: int(record.CaptureLength),
+		Length:        int(record.Length),
+		LinkType:      r.header.LinkType,
+		Data:          record.Data,
+	}, nil
+}
+
+// nextBlock reads the next block, a section header changing the byte order and resetting the interfaces, and
+// an interface description adding an interface
+func (r *Reader) nextBlock() (*Block, error) {
+	var header [BlockHeaderSize + 4]byte
+	if _, err := io.ReadFull(r.r, header[:BlockHeaderSize]); err != nil {
+		return nil, err
+	}
+	if BlockType(binary.BigEndian.Uint32(header[:4])) == BlockSectionHeader {
+		if _, err := io.ReadFull(r.r, header[BlockHeaderSize:]); err != nil {
+			return nil, unexpected(err)
+		}
+		order, _ := byteOrderOf(header[BlockHeaderSize:], ByteOrderMagic)
+		if order == nil {
+			return nil, &MagicError{Magic: binary.BigEndian.Uint32(header[BlockHeaderSize:])}
+		}
+		r.options.ByteOrder = order
+		r.interfaces = nil
+	} else if r.options.ByteOrder == nil {
+		return nil, &MagicError{Magic: binary.BigEndian.Uint32(header[:4])}
+	}
+	t, length := BlockType(r.options.ByteOrder.Uint32(header[:4])), r.options.ByteOrder.Uint32(header[4:8])
+	if length < 12 || length%4 != 0 || length > MaxBlockLength {
+		return nil, &BlockLengthError{Type: t, Length: length}
+	}
+	n := BlockHeaderSize
+	if t == BlockSectionHeader {
+		n += 4
+	}
+	b := make([]byte, length)
+	copy(b, header[:n])
+	if _, err := io.ReadFull(r.r, b[n:]); err != nil {
+		return nil, unexpected(err)
+	}
+	block := &Block{}
+	if err := r.options.Unmarshal(b, block); err != nil {
+		return nil, err
+	}
+	if block.TrailingLength != block.Length {
+		return nil, &BlockLengthError{Type: t, Length: block.TrailingLength}
+	}
+	if idb, ok := block.Body.(*InterfaceDescription); ok {
+		i := Interface{LinkType: uint32(idb.LinkType), SnapLen: idb.SnapLen, Resolution: idb.Resolution()}
+		if o := findOption(idb.Options, OptionTsOffset); o != nil && len(o.Value) == 8 {
+			i.Offset = int64(r.options.ByteOrder.Uint64(o.Value))
+		}
+		r.interfaces = append(r.interfaces, i)
+	}
+	return block, nil
+}
+
+// packet returns the packet of the interface, the timestamp being in the resolution of the interface
+func (r *Reader) packet(id uint32, timestamp uint64, captureLength, length uint32, data []byte) (*Packet, error) {
+	if int(id) >= len(r.interfaces) {
+		return nil, &InterfaceError{InterfaceID: id, Interfaces: len(r.interfaces)}
+	}
+	i := r.interfaces[id]
+	return &Packet{
+		Timestamp:      i.Resolution.Time(timestamp).Add(time.Duration(i.Offset) * time.Second),
+		CaptureLength:  int(captureLength),
+		Length:         int(length),
+		InterfaceIndex: int(id),
+		LinkType:       i.LinkType,
+		Data:           data,
+	}, nil
+}
+
+// unexpected returns io.ErrUnexpectedEOF for io.EOF, as the stream ends in the middle of a record or a block
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package pcap
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/nickchen/packet"
+)
+
+// Writer writes packets to a pcap or pcapng stream with a single interface
+type Writer struct {
+	// Options for encoding, whose ByteOrder is the byte order of the file, big endian when it's nil
+	Options packet.MarshalOptions
+	// LinkType of the packets
+	LinkType uint32
+	// SnapLen the packets are truncated to, DefaultSnapLen when it's zero
+	SnapLen uint32
+	// Resolution of the timestamps, Microseconds when it's zero. pcap supports only Microseconds and Nanoseconds.
+	Resolution Resolution
+
+	ng      bool
+	started bool
+	w       io.Writer
+}
+
+// NewWriter returns a Writer writing pcap to w
+func NewWriter(w io.Writer, linkType uint32) *Writer {
+	return &Writer{LinkType: linkType, w: w}
+}
+
+// NewNgWriter returns a Writer writing pcapng to w
+func NewNgWriter(w io.Writer, linkType uint32) *Writer {
+	return &Writer{LinkType: linkType, ng: true, w: w}
+}
+
+func (w *Writer) snapLen() uint32 {
+	if w.SnapLen == 0 {
+		return DefaultSnapLen
+	}
+	return w.SnapLen
+}
+
+func (w *Writer) resolution() Resolution {
+	if w.Resolution == 0 {
+		return Microseconds
+	}
+	return w.Resolution
+}
+
+func (w *Writer) order() binary.ByteOrder {
+	if w.Options.ByteOrder == nil {
+		return binary.BigEndian
+	}
+	return w.Options.ByteOrder
+}
+
+// WritePacket writes the Data of the packet with its Timestamp, the Length being the length of the Data when it's
+// zero. The Data is truncated to the SnapLen, and the other fields are ignored.
+func (w *Writer) WritePacket(p *Packet) error {
+	if err := w.WriteHeader(); err != nil {
+		return err
+	}
+	timestamp, data, length := p.Timestamp, p.Data, p.Length
+	if length == 0 {
+		length = len(data)
+	}
+	if uint32(len(data)) > w.snapLen() {
+		data = data[:w.snapLen()]
+	}
+	if w.ng {
+		t := w.resolution().Timestamp(timestamp)
+		return w.writeBlock(&EnhancedPacket{
+			TimestampHigh: uint32(t >> 32),
+			TimestampLow:  uint32(t),
+			CaptureLength: uint32(len(data)),
+			Length:        uint32(length),
+			Data:          data,
+		})
+	}
+	fraction := uint32(timestamp.Nanosecond() / 1000)
+	if w.resolution() == Nanoseconds {
+		fraction = uint32(timestamp.Nanosecond())
+	}
+	b, err := w.Options.Marshal(&Record{
+		Seconds:       uint32(timestamp.Unix()),
+		Fraction:      fraction,
+		CaptureLength: uint32(len(data)),
+		Length:        uint32(length),
+		Data:          data,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(b)
+	return err
+}
+
+// WriteHeader writes the file header, or the section header and the interface description for pcapng, which is
+// done by the first WritePacket otherwise. It's needed for a capture without packets.
+func (w *Writer) WriteHeader() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+	if w.ng {
+		if err := w.writeBlock(&SectionHeader{
+			ByteOrderMagic: ByteOrderMagic,
+			VersionMajor:   1,
+			SectionLength:  SectionLengthUnspecified,
+		}); err != nil {
+			return err
+		}
+		idb := &InterfaceDescription{LinkType: uint16(w.LinkType), SnapLen: w.snapLen()}
+		if w.resolution() != Microseconds {
+			idb.Options = []Option{Option{Code: OptionTsResol, Value: []byte{uint8(w.resolution())}}, Option{Code: OptionEnd}}
+		}
+		return w.writeBlock(idb)
+	}
+	magic := MagicMicroseconds
+	if w.resolution() == Nanoseconds {
+		magic = MagicNanoseconds
+	}
+	b, err := w.Options.Marshal(&Header{
+		Magic:        magic,
+		VersionMajor: VersionMajor,
+		VersionMinor: VersionMinor,
+		SnapLen:      w.snapLen(),
+		LinkType:     w.LinkType,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(b)
+	return err
+}
+
+// writeBlock encodes the block of the body, the body is padded to 4 bytes and both lengths are set
+func (w *Writer) writeBlock(body interface{}) error {
+	b, err := w.Options.Marshal(&Block{Body: body})
+	if err != nil {
+		return err
+	}
+	if pad := -len(b) & 3; pad != 0 {
+		trailing := len(b) - 4
+		b = append(b[:trailing], make([]byte, pad+4)...)
+	}
+	length := uint32(len(b))
+	w.order().PutUint32(b[4:8], length)
+	w.order().PutUint32(b[len(b)-4:], length)
+	_, err = w.w.Write(b)
+	return err
+}
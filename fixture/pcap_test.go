@@ -0,0 +1,233 @@
+package fixture
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickchen/packet"
+	"github.com/nickchen/packet/fixture/pcap"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCapture writes the packets to a temporary pcap file with the snapshot length, returning the name of the file
+func writeCapture(t *testing.T, snapLen uint32, packets ...*pcap.Packet) string {
+	f, err := ioutil.TempFile("", "fixture*.pcap")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+	w := pcap.NewWriter(f, uint32(LinkTypeEthernet))
+	w.SnapLen = snapLen
+	for _, p := range packets {
+		assert.NoError(t, w.WritePacket(p))
+	}
+	return f.Name()
+}
+
+func TestPcapNextPacket(t *testing.T) {
+	// the option of IPv4 is cut short at 36 bytes
+	b, err := packet.Marshal(&EthernetII{Type: _IPv4, Body: &IPv4{Version: 4, Length: 32, TTL: 64, Protocol: 253,
+		Source: net.IP{192, 168, 0, 1}, Dest: net.IP{192, 168, 0, 2},
+		Options: []IPv4Option{IPv4Option{Type: RouterAlert, Value: &RouterAlertOption{}}},
+		Body:    &packet.Raw{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}}})
+	assert.NoError(t, err)
+	timestamp := time.Unix(1600000000, 123456000)
+
+	file := writeCapture(t, 36, &pcap.Packet{Timestamp: timestamp, Data: b[:36]}, &pcap.Packet{Timestamp: timestamp, Data: b})
+	defer os.Remove(file)
+	p, err := OpenPCAP(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer p.Close()
+
+	// captured as a whole
+	captured, err := p.NextPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, &CapturedPacket{Timestamp: timestamp, CaptureLength: 36, Length: 36, LinkType: LinkTypeEthernet, Data: b[:36]}, captured)
+	assert.NoError(t, captured.Truncated())
+	_, err = captured.Decode()
+	assert.IsType(t, &packet.UnmarshalUnexpectedEnd{}, err, "malformed")
+
+	// truncated by the snapshot length
+	captured, err = p.NextPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, &CapturedPacket{Timestamp: timestamp, CaptureLength: 36, Length: 46, LinkType: LinkTypeEthernet, Data: b[:36]}, captured)
+	assert.Equal(t, &TruncatedError{CaptureLength: 36, Length: 46}, captured.Truncated())
+	v, err := captured.Decode()
+	assert.IsType(t, &TruncatedError{}, err)
+	assert.IsType(t, &packet.UnmarshalUnexpectedEnd{}, err.(*TruncatedError).Err)
+	assert.IsType(t, &EthernetII{}, v)
+
+	_, err = p.NextPacket()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestPcapTruncatedTCP(t *testing.T) {
+	// the TCP header is cut short at 40 bytes, in the middle of the sequence number
+	b, err := packet.Marshal(&EthernetII{Type: _IPv4, Body: &IPv4{Version: 4, IHL: 5, Length: 44, TTL: 64, Protocol: _TCP,
+		Source: net.IP{192, 168, 0, 1}, Dest: net.IP{192, 168, 0, 2},
+		Body: &TCP{Source: 35000, Dest: 80, Sequence: 1, DataOffset: 5, Flags: SYN, WindowSize: 0xffff,
+			Body: &packet.Raw{0x01, 0x02, 0x03, 0x04}}}})
+	assert.NoError(t, err)
+	assert.Equal(t, 58, len(b))
+
+	file := writeCapture(t, 40, &pcap.Packet{Timestamp: time.Unix(1600000000, 0), Data: b})
+	defer os.Remove(file)
+	p, err := OpenPCAP(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer p.Close()
+
+	captured, err := p.NextPacket()
+	assert.NoError(t, err)
+	assert.Equal(t, 40, captured.CaptureLength)
+	assert.Equal(t, 58, captured.Length)
+	v, err := captured.Decode()
+	if assert.IsType(t, &TruncatedError{}, err) {
+		assert.Equal(t, 40, err.(*TruncatedError).CaptureLength)
+		assert.Error(t, err.(*TruncatedError).Err, "decoding the short packet")
+	}
+	assert.IsType(t, &EthernetII{}, v)
+}
+
+func TestPcapIterate(t *testing.T) {
+	packets := []*pcap.Packet{}
+	for i := 0; i < 20; i++ {
+		packets = append(packets, &pcap.Packet{Timestamp: time.Unix(1600000000+int64(i), 0), Data: []byte{uint8(i)}})
+	}
+	file := writeCapture(t, 0, packets...)
+	defer os.Remove(file)
+
+	p, err := OpenPCAP(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	count := 0
+	for p.Next() {
+		assert.Equal(t, PacketData{uint8(count)}, p.Packet().Data)
+		count++
+	}
+	assert.NoError(t, p.Err())
+	assert.Equal(t, 20, count)
+	assert.False(t, p.Next())
+	assert.NoError(t, p.Close())
+	assert.Error(t, p.Close(), "closed already")
+
+	// the channel is closed at the end of the capture
+	p, _ = OpenPCAP(file)
+	count = 0
+	for packet := range p.Packets(context.Background()) {
+		assert.Equal(t, PacketData{uint8(count)}, packet.Data)
+		count++
+	}
+	assert.NoError(t, p.Err())
+	assert.Equal(t, 20, count)
+	assert.NoError(t, p.Close())
+
+	// the channel is closed when the context is canceled
+	p, _ = OpenPCAP(file)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	count = 0
+	for range p.Packets(ctx) {
+		if count++; count == 2 {
+			cancel()
+		}
+	}
+	assert.Equal(t, context.Canceled, p.Err())
+	assert.True(t, count < 20)
+	assert.NoError(t, p.Close())
+
+	// the goroutine exits on Close, with the channel left full
+	p, _ = OpenPCAP(file)
+	channel := p.Packets(context.Background())
+	<-channel
+	assert.NoError(t, p.Close())
+	for range channel {
+	}
+	assert.NoError(t, p.Err())
+
+	// the read error is reported once the channel is closed
+	b, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(file, b[:len(b)-1], 0644))
+	p, _ = OpenPCAP(file)
+	count = 0
+	for range p.Packets(context.Background()) {
+		count++
+	}
+	assert.Equal(t, 19, count)
+	assert.Equal(t, io.ErrUnexpectedEOF, p.Err())
+	assert.NoError(t, p.Close())
+}
+
+func TestPcapWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixture")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	ether := &EthernetII{
+		Source: Mac{0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77},
+		Dest:   Mac{0xfa, 0x16, 0x3e, 0x1a, 0x43, 0xcb},
+		Body: &IPv4{Version: 4, IHL: 5, Length: 28, TTL: 64, Source: net.IP{192, 168, 0, 1}, Dest: net.IP{192, 168, 0, 2},
+			Body: &UDP{Source: 1234, Dest: 5678, Length: 8}},
+	}
+	timestamp := time.Unix(1600000000, 123456000)
+	for _, name := range []string{"test.pcap", "test.pcapng"} {
+		file := filepath.Join(dir, name)
+		w, err := CreatePCAP(file, LinkTypeEthernet)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		assert.NoError(t, w.Write(timestamp, ether), name)
+		assert.NoError(t, w.WritePacket(&CapturedPacket{Timestamp: timestamp, Length: 100, Data: PacketData{0x01, 0x02}}), name)
+		assert.NoError(t, w.Write(time.Time{}, ether), name)
+		assert.NoError(t, w.Close(), name)
+
+		p, err := OpenPCAP(file)
+		if !assert.NoError(t, err, name) {
+			continue
+		}
+		assert.True(t, p.Next(), name)
+		assert.Equal(t, timestamp, p.Packet().Timestamp, name)
+		v, err := p.Packet().Decode()
+		assert.NoError(t, err, name)
+		// the discriminators are filled in
+		assert.Equal(t, _IPv4, v.(*EthernetII).Type, name)
+		assert.Equal(t, _UDP, v.(*EthernetII).Body.(*IPv4).Protocol, name)
+		assert.Equal(t, ether.Body.(*IPv4).Body, v.(*EthernetII).Body.(*IPv4).Body, name)
+
+		assert.True(t, p.Next(), name)
+		assert.Equal(t, &CapturedPacket{Timestamp: timestamp, CaptureLength: 2, Length: 100, LinkType: LinkTypeEthernet, Data: PacketData{0x01, 0x02}}, p.Packet(), name)
+		assert.True(t, p.Next(), name)
+		assert.WithinDuration(t, time.Now(), p.Packet().Timestamp, time.Minute, name)
+		assert.False(t, p.Next(), name)
+		assert.NoError(t, p.Err(), name)
+		assert.Equal(t, strings.HasSuffix(name, ".pcapng"), p.reader.NG(), name)
+		assert.NoError(t, p.Close(), name)
+	}
+
+	// the header is written without packets
+	file := filepath.Join(dir, "empty.pcap")
+	w, err := CreatePCAP(file, LinkTypeRaw)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	p, err := OpenPCAP(file)
+	if assert.NoError(t, err) {
+		assert.Equal(t, LinkTypeRaw, p.LinkType())
+		assert.False(t, p.Next())
+		assert.NoError(t, p.Err())
+		assert.NoError(t, p.Close())
+	}
+}
@@ -0,0 +1,122 @@
+package fixture
+
+import (
+	"fmt"
+
+	"github.com/nickchen/packet"
+)
+
+// TCPOptionKind kind of TCP option, https://www.iana.org/assignments/tcp-parameters
+type TCPOptionKind uint8
+
+const (
+	EOL           TCPOptionKind = 0
+	NOP           TCPOptionKind = 1
+	MSS           TCPOptionKind = 2
+	WindowScale   TCPOptionKind = 3
+	SACKPermitted TCPOptionKind = 4
+	SACK          TCPOptionKind = 5
+	Timestamps    TCPOptionKind = 8
+	MD5Signature  TCPOptionKind = 19
+	TCPAO         TCPOptionKind = 29
+	MPTCP         TCPOptionKind = 30
+)
+
+func (k TCPOptionKind) String() string {
+	switch k {
+	case EOL:
+		return "EOL"
+	case NOP:
+		return "NOP"
+	case MSS:
+		return "MSS"
+	case WindowScale:
+		return "WindowScale"
+	case SACKPermitted:
+		return "SACKPermitted"
+	case SACK:
+		return "SACK"
+	case Timestamps:
+		return "Timestamps"
+	case MD5Signature:
+		return "MD5"
+	case TCPAO:
+		return "TCP-AO"
+	case MPTCP:
+		return "MPTCP"
+	}
+	return fmt.Sprintf("TCPOptionKind(%d)", int(k))
+}
+
+// TCPOption option of TCP, EOL and NOP being a single byte without Length and Value. Length includes Kind and Length.
+type TCPOption struct {
+	Kind   TCPOptionKind `packet:"tlv=type"`
+	Length uint8         `packet:"tlv=length"`
+	Value  interface{}   `packet:"tlv=value"`
+}
+
+var tcpOptionSpec = &packet.TLVSpec{
+	Padding:   []uint64{uint64(EOL), uint64(NOP)},
+	Inclusive: true,
+	Registry: map[uint64]interface{}{
+		uint64(MSS):           &MSSOption{},
+		uint64(WindowScale):   &WindowScaleOption{},
+		uint64(SACKPermitted): &SACKPermittedOption{},
+		uint64(SACK):          &[]SACKBlock{},
+		uint64(Timestamps):    &TimestampsOption{},
+		uint64(MD5Signature):  &MD5SignatureOption{},
+		uint64(TCPAO):         &TCPAOOption{},
+		uint64(MPTCP):         &MPTCPOption{},
+	},
+}
+
+// TLV returns the TLV spec of TCP options
+func (o TCPOption) TLV() *packet.TLVSpec {
+	return tcpOptionSpec
+}
+
+// MSSOption maximum segment size, https://tools.ietf.org/html/rfc793#section-3.1
+type MSSOption struct {
+	MSS uint16
+}
+
+// WindowScaleOption window scale, https://tools.ietf.org/html/rfc7323#section-2
+type WindowScaleOption struct {
+	Shift uint8
+}
+
+// SACKPermittedOption SACK permitted, https://tools.ietf.org/html/rfc2018#section-2
+type SACKPermittedOption struct {
+}
+
+// SACKBlock block of SACK option, https://tools.ietf.org/html/rfc2018#section-3
+type SACKBlock struct {
+	Left  uint32
+	Right uint32
+}
+
+// TimestampsOption timestamps, https://tools.ietf.org/html/rfc7323#section-3
+type TimestampsOption struct {
+	Value     uint32
+	EchoReply uint32
+}
+
+// MD5SignatureOption MD5 signature, https://tools.ietf.org/html/rfc2385#section-3.0
+type MD5SignatureOption struct {
+	Digest [16]byte
+}
+
+// TCPAOOption TCP authentication option, https://tools.ietf.org/html/rfc5925#section-2.2
+type TCPAOOption struct {
+	KeyID      uint8
+	RNextKeyID uint8
+	MAC        []byte
+}
+
+// MPTCPOption multipath TCP, https://tools.ietf.org/html/rfc8684#section-3, Flags being subtype specific,
+// such as the version of MP_CAPABLE
+type MPTCPOption struct {
+	Subtype uint8 `packet:"length=4b"`
+	Flags   uint8 `packet:"length=4b"`
+	Data    []byte
+}
@@ -0,0 +1,111 @@
+package fixture
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+var tcpOptionTests = []struct {
+	name    string
+	b       []byte
+	options []TCPOption
+}{
+	{
+		name: "SYN",
+		b: []byte{
+			0x02, 0x04, 0x05, 0xb4, 0x04, 0x02, 0x08, 0x0a, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+			0x01, 0x03, 0x03, 0x07,
+		},
+		options: []TCPOption{
+			TCPOption{Kind: MSS, Length: 4, Value: &MSSOption{MSS: 1460}},
+			TCPOption{Kind: SACKPermitted, Length: 2, Value: &SACKPermittedOption{}},
+			TCPOption{Kind: Timestamps, Length: 10, Value: &TimestampsOption{Value: 1}},
+			TCPOption{Kind: NOP},
+			TCPOption{Kind: WindowScale, Length: 3, Value: &WindowScaleOption{Shift: 7}},
+		},
+	},
+	{
+		name: "SACK",
+		b: []byte{
+			0x01, 0x01, 0x05, 0x12, 0x00, 0x00, 0x03, 0xe8, 0x00, 0x00, 0x07, 0xd0, 0x00, 0x00, 0x0b, 0xb8,
+			0x00, 0x00, 0x0f, 0xa0,
+		},
+		options: []TCPOption{
+			TCPOption{Kind: NOP},
+			TCPOption{Kind: NOP},
+			TCPOption{Kind: SACK, Length: 18, Value: &[]SACKBlock{
+				SACKBlock{Left: 1000, Right: 2000},
+				SACKBlock{Left: 3000, Right: 4000},
+			}},
+		},
+	},
+	{
+		name: "MD5",
+		b: []byte{
+			0x13, 0x12, 0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d,
+			0x0e, 0x0f, 0x00, 0x00,
+		},
+		options: []TCPOption{
+			TCPOption{Kind: MD5Signature, Length: 18, Value: &MD5SignatureOption{
+				Digest: [16]byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d, 0x0e, 0x0f},
+			}},
+			TCPOption{Kind: EOL},
+			TCPOption{Kind: EOL},
+		},
+	},
+	{
+		name: "TCP-AO and MPTCP",
+		b: []byte{
+			0x1d, 0x06, 0x01, 0x02, 0xaa, 0xbb, 0x01, 0x01,
+			0x1e, 0x0c, 0x00, 0x81, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		},
+		options: []TCPOption{
+			TCPOption{Kind: TCPAO, Length: 6, Value: &TCPAOOption{KeyID: 1, RNextKeyID: 2, MAC: []byte{0xaa, 0xbb}}},
+			TCPOption{Kind: NOP},
+			TCPOption{Kind: NOP},
+			TCPOption{Kind: MPTCP, Length: 12, Value: &MPTCPOption{Subtype: 0, Flags: 0,
+				Data: []byte{0x81, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}}},
+		},
+	},
+}
+
+func TestTCPOptions(t *testing.T) {
+	for _, test := range tcpOptionTests {
+		t.Run(test.name, func(t *testing.T) {
+			tcp := &TCP{}
+			header := []byte{0x04, 0xd2, 0x00, 0x50, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+				byte(5+len(test.b)/4) << 4, 0x02, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00}
+			b := append(header, test.b...)
+			assert.NoError(t, packet.Unmarshal(b, tcp), "failed to decode")
+			assert.Empty(t, cmp.Diff(test.options, tcp.Options), "diff found")
+
+			encoded, err := packet.Marshal(tcp)
+			assert.NoError(t, err, "failed to encode")
+			assert.Equal(t, b, encoded, "same data")
+		})
+	}
+}
+
+func TestTCPOptionsInstanceType(t *testing.T) {
+	b, err := packet.Marshal(&[]TCPOption{
+		TCPOption{Length: 4, Value: &MSSOption{MSS: 1460}},
+		TCPOption{Kind: NOP},
+		TCPOption{Length: 3, Value: &WindowScaleOption{Shift: 7}},
+	})
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, []byte{0x02, 0x04, 0x05, 0xb4, 0x01, 0x03, 0x03, 0x07}, b)
+}
+
+func TestTCPOptionsLength(t *testing.T) {
+	b, err := packet.Marshal(&[]TCPOption{
+		TCPOption{Value: &MSSOption{MSS: 1460}},
+		TCPOption{Kind: NOP},
+		TCPOption{Value: &[]SACKBlock{SACKBlock{Left: 1000, Right: 2000}}},
+	})
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, []byte{0x02, 0x04, 0x05, 0xb4, 0x01,
+		0x05, 0x0a, 0x00, 0x00, 0x03, 0xe8, 0x00, 0x00, 0x07, 0xd0}, b)
+}
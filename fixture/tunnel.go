@@ -0,0 +1,115 @@
+package fixture
+
+import "github.com/nickchen/packet"
+
+// GRE generic routing encapsulation as per https://tools.ietf.org/html/rfc2784, with the key and sequence number of
+// https://tools.ietf.org/html/rfc2890, the optional fields being present by the flags. The Checksum and Offset
+// (Reserved1 of RFC 2784) are present when either ChecksumPresent or RoutingPresent is set, as per RFC 1701.
+// Routing of RFC 1701 is not decoded, the rest of the packet is kept as the Raw Body when RoutingPresent is set.
+type GRE struct {
+	ChecksumPresent bool
+	RoutingPresent  bool
+	KeyPresent      bool
+	SequencePresent bool
+	Reserved0       uint16 `packet:"length=9b"`
+	Version         uint8  `packet:"length=3b"`
+	Protocol        EtherType
+	Checksum        Checksum `packet:"when=ChecksumPresent-eq-1|RoutingPresent-eq-1"`
+	Offset          uint16   `packet:"when=ChecksumPresent-eq-1|RoutingPresent-eq-1"`
+	Key             uint32   `packet:"when=KeyPresent-eq-1"`
+	Sequence        uint32   `packet:"when=SequencePresent-eq-1"`
+	Body            interface{}
+}
+
+// InstanceFor returns the Body struct pointer for conversion, nil when the Body follows the routing
+func (g GRE) InstanceFor(fieldname string) interface{} {
+	if g.RoutingPresent {
+		return nil
+	}
+	return bodyStructEtherType(g.Protocol)
+}
+
+// InstanceType returns the Protocol for the Body struct pointer
+func (g GRE) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	if t := etherTypeBodyStruct(body); t != 0 {
+		return "Protocol", uint64(t)
+	}
+	return "", 0
+}
+
+// VXLAN virtual extensible LAN as per https://tools.ietf.org/html/rfc7348#section-5, carrying ethernet frames
+type VXLAN struct {
+	Flags     uint8
+	Reserved0 uint32 `packet:"length=24b"`
+	VNI       uint32 `packet:"length=24b"`
+	Reserved1 uint8
+	Body      interface{}
+}
+
+// VXLANValidVNI flag of VXLAN, set for a valid VNI
+const VXLANValidVNI uint8 = 0x08
+
+// InstanceFor returns the Body struct pointer for conversion
+func (v VXLAN) InstanceFor(fieldname string) interface{} {
+	return &EthernetII{}
+}
+
+// Geneve generic network virtualization encapsulation as per https://tools.ietf.org/html/rfc8926#section-3.4,
+// OptionsLength being in 4 bytes unit, filled in from the Options by Marshal
+type Geneve struct {
+	Version       uint8 `packet:"length=2b"`
+	OptionsLength uint8 `packet:"length=6b,tlv=length"`
+	OAM           bool
+	Critical      bool
+	Reserved0     uint8 `packet:"length=6b"`
+	Protocol      EtherType
+	VNI           uint32 `packet:"length=24b"`
+	Reserved1     uint8
+	Options       []GeneveOption `packet:"tlv=value"`
+	Body          interface{}
+}
+
+var geneveSpec = &packet.TLVSpec{
+	Unit: 4,
+}
+
+// TLV returns the spec of the header, OptionsLength being the length of the Options
+func (g Geneve) TLV() *packet.TLVSpec {
+	return geneveSpec
+}
+
+// InstanceFor returns the Body struct pointer for conversion
+func (g Geneve) InstanceFor(fieldname string) interface{} {
+	return bodyStructEtherType(g.Protocol)
+}
+
+// InstanceType returns the Protocol for the Body struct pointer
+func (g Geneve) InstanceType(fieldname string, body interface{}) (string, uint64) {
+	if t := etherTypeBodyStruct(body); t != 0 {
+		return "Protocol", uint64(t)
+	}
+	return "", 0
+}
+
+// GeneveOption option of Geneve, Length being in 4 bytes unit not including the header
+type GeneveOption struct {
+	Class    uint16
+	Type     uint8  `packet:"tlv=type"`
+	Reserved uint8  `packet:"length=3b"`
+	Length   uint8  `packet:"length=5b,tlv=length"`
+	Data     []byte `packet:"tlv=value"`
+}
+
+var geneveOptionSpec = &packet.TLVSpec{
+	Unit: 4,
+}
+
+// TLV returns the TLV spec of Geneve options
+func (o GeneveOption) TLV() *packet.TLVSpec {
+	return geneveOptionSpec
+}
+
+// Critical returns true when the option must be understood by the receiver
+func (o GeneveOption) Critical() bool {
+	return o.Type&0x80 != 0
+}
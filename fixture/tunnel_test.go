@@ -0,0 +1,172 @@
+package fixture
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+var payload = []byte("0123456789abcdef0123456789abcdef")
+
+// innerFrame returns the layers of an ethernet frame carrying UDP, over the minimum size to not be padded
+func innerFrame() []gopacket.SerializableLayer {
+	ipv4 := ipv4Layer(layers.IPProtocolUDP)
+	udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ipv4)
+	return []gopacket.SerializableLayer{
+		&layers.Ethernet{SrcMAC: net.HardwareAddr{0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77},
+			DstMAC: net.HardwareAddr{0xfa, 0x16, 0x3e, 0x1a, 0x43, 0xcb}, EthernetType: layers.EthernetTypeIPv4},
+		ipv4, udp, gopacket.Payload(payload),
+	}
+}
+
+func TestGRE(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		gre  *layers.GRE
+		l    []gopacket.SerializableLayer
+	}{
+		{name: "IPv4", gre: &layers.GRE{Protocol: layers.EthernetTypeIPv4}, l: innerFrame()[1:]},
+		{name: "key and sequence", gre: &layers.GRE{KeyPresent: true, SeqPresent: true, Key: 100, Seq: 7, Protocol: layers.EthernetTypeIPv4},
+			l: innerFrame()[1:]},
+		{name: "checksum and TEB", gre: &layers.GRE{ChecksumPresent: true, Protocol: layers.EthernetTypeTransparentEthernetBridging},
+			l: innerFrame()},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := serialize(t, append([]gopacket.SerializableLayer{ipv4Layer(layers.IPProtocolGRE), test.gre}, test.l...)...)
+
+			ip := &IPv4{}
+			assert.NoError(t, packet.Unmarshal(b, ip), "failed to decode")
+			gre, ok := ip.Body.(*GRE)
+			assert.True(t, ok, "failed to find GRE")
+			assert.Equal(t, test.gre.ChecksumPresent, gre.ChecksumPresent)
+			assert.Equal(t, test.gre.Checksum, uint16(gre.Checksum))
+			assert.Equal(t, test.gre.Key, gre.Key)
+			assert.Equal(t, test.gre.Seq, gre.Sequence)
+			if ether, ok := gre.Body.(*EthernetII); ok {
+				assert.IsType(t, &IPv4{}, ether.Body, "ethernet over GRE")
+			} else {
+				assert.IsType(t, &IPv4{}, gre.Body, "IPv4 over GRE")
+			}
+
+			encoded, err := packet.Marshal(ip)
+			assert.NoError(t, err, "failed to encode")
+			assert.Equal(t, b, encoded, "same data")
+		})
+	}
+}
+
+func TestGRERouting(t *testing.T) {
+	// RFC 1701 with checksum, offset and a routing SRE of one IPv4 address before the end SRE
+	b := serialize(t, ipv4Layer(layers.IPProtocolGRE), gopacket.Payload{
+		0xc0, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x08, 0x00, 0x00, 0x04, 0x0a, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00,
+	})
+	ip := &IPv4{}
+	assert.NoError(t, packet.Unmarshal(b, ip), "failed to decode")
+	gre, ok := ip.Body.(*GRE)
+	if assert.True(t, ok, "failed to find GRE") {
+		assert.True(t, gre.RoutingPresent)
+		assert.Equal(t, &packet.Raw{0x08, 0x00, 0x00, 0x04, 0x0a, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}, gre.Body, "routing kept as raw")
+	}
+
+	encoded, err := packet.Marshal(ip)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+
+	// routing without checksum still has the checksum and offset, before the key
+	b = serialize(t, ipv4Layer(layers.IPProtocolGRE), gopacket.Payload{
+		0x60, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x64,
+		0x08, 0x00, 0x00, 0x04, 0x0a, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00,
+	})
+	ip = &IPv4{}
+	assert.NoError(t, packet.Unmarshal(b, ip), "failed to decode")
+	gre, ok = ip.Body.(*GRE)
+	if assert.True(t, ok, "failed to find GRE") {
+		assert.False(t, gre.ChecksumPresent)
+		assert.Equal(t, uint32(100), gre.Key, "key after the offset")
+		assert.Equal(t, &packet.Raw{0x08, 0x00, 0x00, 0x04, 0x0a, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}, gre.Body, "routing kept as raw")
+	}
+
+	encoded, err = packet.Marshal(ip)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+}
+
+func TestVXLAN(t *testing.T) {
+	ipv4 := ipv4Layer(layers.IPProtocolUDP)
+	udp := &layers.UDP{SrcPort: 49152, DstPort: 4789}
+	udp.SetNetworkLayerForChecksum(ipv4)
+	b := serialize(t, append([]gopacket.SerializableLayer{ipv4, udp, &layers.VXLAN{ValidIDFlag: true, VNI: 5000}}, innerFrame()...)...)
+
+	ip := &IPv4{}
+	assert.NoError(t, packet.Unmarshal(b, ip), "failed to decode")
+	vxlan, ok := ip.Body.(*UDP).Body.(*VXLAN)
+	assert.True(t, ok, "failed to find VXLAN")
+	assert.Equal(t, VXLANValidVNI, vxlan.Flags)
+	assert.Equal(t, uint32(5000), vxlan.VNI)
+	ether, ok := vxlan.Body.(*EthernetII)
+	assert.True(t, ok, "failed to find the inner frame")
+	assert.Equal(t, packet.Raw(payload), *ether.Body.(*IPv4).Body.(*UDP).Body.(*packet.Raw))
+
+	encoded, err := packet.Marshal(ip)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+}
+
+func TestGeneve(t *testing.T) {
+	inner := serialize(t, innerFrame()...)
+	b := append([]byte{
+		0x03, 0x80, 0x65, 0x58, 0x00, 0x13, 0x88, 0x00,
+		0x01, 0x02, 0x80, 0x01, 0xaa, 0xbb, 0xcc, 0xdd,
+		0xff, 0xff, 0x03, 0x00,
+	}, inner...)
+
+	g := &Geneve{}
+	assert.NoError(t, packet.Unmarshal(b, g), "failed to decode")
+	assert.Empty(t, cmp.Diff([]GeneveOption{
+		GeneveOption{Class: 0x0102, Type: 0x80, Length: 1, Data: []byte{0xaa, 0xbb, 0xcc, 0xdd}},
+		GeneveOption{Class: 0xffff, Type: 0x03},
+	}, g.Options), "diff found")
+	assert.True(t, g.Options[0].Critical())
+	assert.True(t, g.OAM)
+	assert.Equal(t, uint8(3), g.OptionsLength)
+	assert.Equal(t, uint32(5000), g.VNI)
+	assert.IsType(t, &EthernetII{}, g.Body, "ethernet over Geneve")
+
+	gp := gopacket.NewPacket(b, layers.LayerTypeGeneve, gopacket.Default)
+	gpGeneve, _ := gp.Layer(layers.LayerTypeGeneve).(*layers.Geneve)
+	assert.NotNil(t, gpGeneve, "geneve layer decoded")
+	assert.Equal(t, gpGeneve.VNI, g.VNI)
+	assert.Equal(t, uint16(gpGeneve.Protocol), uint16(g.Protocol))
+
+	encoded, err := packet.Marshal(g)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+
+	// lengths and padding are filled in
+	g.OptionsLength, g.Options[0].Length = 0, 0
+	g.Options[0].Data = g.Options[0].Data[:3]
+	encoded, err = packet.Marshal(g)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, append(append(b[:15:15], 0x00), b[16:]...), encoded, "padded data")
+}
+
+func TestTunnelDepth(t *testing.T) {
+	l := innerFrame()[1:]
+	for i := 0; i < packet.DefaultMaxDepth/2; i++ {
+		l = append([]gopacket.SerializableLayer{ipv4Layer(layers.IPProtocolGRE), &layers.GRE{Protocol: layers.EthernetTypeIPv4}}, l...)
+	}
+	b := serialize(t, l...)
+
+	err := packet.Unmarshal(b, &IPv4{})
+	assert.IsType(t, &packet.UnmarshalDepthError{}, err)
+	assert.NoError(t, packet.UnmarshalOptions{MaxDepth: packet.DefaultMaxDepth * 2}.Unmarshal(b, &IPv4{}))
+}
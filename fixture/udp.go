@@ -0,0 +1,32 @@
+package fixture
+
+// UDP datagram as per https://tools.ietf.org/html/rfc768, Length includes the header
+type UDP struct {
+	Source   Port
+	Dest     Port
+	Length   uint16
+	Checksum Checksum
+	Body     interface{} `packet:"lengthfor"`
+}
+
+// UDPHeaderSize the size of the UDP header
+const UDPHeaderSize = 8
+
+// InstanceFor returns the Body struct pointer for conversion
+func (udp UDP) InstanceFor(fieldname string) interface{} {
+	switch udp.Dest {
+	case _VXLAN:
+		return &VXLAN{}
+	case _Geneve:
+		return &Geneve{}
+	}
+	return nil
+}
+
+// LengthFor returns the length in bytes for the provided field
+func (udp UDP) LengthFor(fieldname string) uint64 {
+	if udp.Length < UDPHeaderSize {
+		return 0
+	}
+	return uint64(udp.Length - UDPHeaderSize)
+}
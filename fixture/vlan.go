@@ -0,0 +1,43 @@
+package fixture
+
+// VLANTag tag of the VLAN stack, TPID being the EtherType preceding the tag
+type VLANTag struct {
+	TPID     EtherType
+	Priority uint8
+	DEI      bool
+	ID       uint16
+}
+
+// VLANs returns the VLAN stack, from outer to inner tag
+func (e *EthernetII) VLANs() []VLANTag {
+	var tags []VLANTag
+	tpid := e.Type
+	for body := e.Body; ; {
+		v, ok := body.(*VLAN)
+		if !ok {
+			return tags
+		}
+		tags = append(tags, VLANTag{TPID: tpid, Priority: v.Priority, DEI: v.DEI, ID: v.ID})
+		tpid, body = v.Type, v.Body
+	}
+}
+
+// PushVLAN adds the tag as the outer tag, carrying the Type of the frame, TPID being 0x8100 when it's zero
+func (e *EthernetII) PushVLAN(tag VLANTag) {
+	if tag.TPID == 0 {
+		tag.TPID = TPID8021Q
+	}
+	e.Body = &VLAN{Priority: tag.Priority, DEI: tag.DEI, ID: tag.ID, Type: e.Type, Body: e.Body}
+	e.Type = tag.TPID
+}
+
+// PopVLAN removes the outer tag, restoring the Type of the frame from the tag
+func (e *EthernetII) PopVLAN() (VLANTag, bool) {
+	v, ok := e.Body.(*VLAN)
+	if !ok {
+		return VLANTag{}, false
+	}
+	tag := VLANTag{TPID: e.Type, Priority: v.Priority, DEI: v.DEI, ID: v.ID}
+	e.Type, e.Body = v.Type, v.Body
+	return tag, true
+}
@@ -0,0 +1,88 @@
+package fixture
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/nickchen/packet"
+	"github.com/stretchr/testify/assert"
+)
+
+func ethernetLayers(t *testing.T, tags ...*layers.Dot1Q) []byte {
+	ether := &layers.Ethernet{SrcMAC: net.HardwareAddr{0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77},
+		DstMAC: net.HardwareAddr{0xfa, 0x16, 0x3e, 0x1a, 0x43, 0xcb}, EthernetType: layers.EthernetTypeIPv4}
+	l := []gopacket.SerializableLayer{ether}
+	previous := &ether.EthernetType
+	for i, tag := range tags {
+		*previous = layers.EthernetTypeDot1Q
+		if i == 0 && len(tags) > 1 {
+			*previous = layers.EthernetTypeQinQ
+		}
+		tag.Type = layers.EthernetTypeIPv4
+		previous = &tag.Type
+		l = append(l, tag)
+	}
+	ipv4 := ipv4Layer(layers.IPProtocolUDP)
+	udp := &layers.UDP{SrcPort: 5000, DstPort: 53}
+	udp.SetNetworkLayerForChecksum(ipv4)
+	b := serialize(t, append(l, ipv4, udp)...)
+	// gopacket pads the frame to the minimum size, which is not part of the IPv4 packet
+	return b[:14+4*len(tags)+int(ipv4.Length)]
+}
+
+func TestQinQ(t *testing.T) {
+	b := ethernetLayers(t, &layers.Dot1Q{Priority: 3, VLANIdentifier: 100}, &layers.Dot1Q{DropEligible: true, VLANIdentifier: 200})
+	single := ethernetLayers(t, &layers.Dot1Q{DropEligible: true, VLANIdentifier: 200})
+	untagged := ethernetLayers(t)
+
+	ether := &EthernetII{}
+	assert.NoError(t, packet.Unmarshal(b, ether), "failed to decode")
+	assert.Equal(t, []VLANTag{
+		VLANTag{TPID: TPID8021AD, Priority: 3, ID: 100},
+		VLANTag{TPID: TPID8021Q, DEI: true, ID: 200},
+	}, ether.VLANs())
+	assert.Equal(t, "QinQ", ether.Type.String())
+
+	tag, ok := ether.PopVLAN()
+	assert.True(t, ok, "outer tag")
+	assert.Equal(t, VLANTag{TPID: TPID8021AD, Priority: 3, ID: 100}, tag)
+	encoded, err := packet.Marshal(ether)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, single, encoded, "single tag")
+
+	inner, ok := ether.PopVLAN()
+	assert.True(t, ok, "inner tag")
+	_, ok = ether.PopVLAN()
+	assert.False(t, ok, "untagged")
+	assert.Nil(t, ether.VLANs())
+	encoded, err = packet.Marshal(ether)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, untagged, encoded, "untagged")
+
+	ether.PushVLAN(VLANTag{DEI: true, ID: 200})
+	assert.Equal(t, []VLANTag{inner}, ether.VLANs())
+	ether.PushVLAN(tag)
+	encoded, err = packet.Marshal(ether)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "double tagged")
+}
+
+func TestQinQ9100(t *testing.T) {
+	b := ethernetLayers(t, &layers.Dot1Q{VLANIdentifier: 100}, &layers.Dot1Q{VLANIdentifier: 200})
+	b[12], b[13] = 0x91, 0x00
+
+	ether := &EthernetII{}
+	assert.NoError(t, packet.Unmarshal(b, ether), "failed to decode")
+	assert.Equal(t, []VLANTag{
+		VLANTag{TPID: TPID9100, ID: 100},
+		VLANTag{TPID: TPID8021Q, ID: 200},
+	}, ether.VLANs())
+	vlan := ether.Body.(*VLAN).Body.(*VLAN)
+	assert.IsType(t, &IPv4{}, vlan.Body, "ipv4 after the tags")
+
+	encoded, err := packet.Marshal(ether)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, b, encoded, "same data")
+}
@@ -0,0 +1,286 @@
+package packet_test
+
+// the fixtures import packet, so the tests decoding them are external
+import (
+	"fmt"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/nickchen/packet"
+	"github.com/nickchen/packet/fixture"
+	"github.com/nickchen/packet/fixture/bgp"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+var frame = []byte{
+	0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77, 0xfa, 0x16, /* ..>..w.. */
+	0x3e, 0x1a, 0x43, 0xcb, 0x81, 0x00, 0x0f, 0xfe, /* >.C..... */
+	0x08, 0x00, 0x45, 0x00, 0x00, 0x6b, 0x9a, 0xaf, /* ..E..k.. */
+	0x40, 0x00, 0x01, 0x06, 0xca, 0xa2, 0x0a, 0x14, /* @....... */
+	0x00, 0x0a, 0x0a, 0x0a, 0x00, 0x14, 0x89, 0xce, /* ........ */
+	0x00, 0xb3, 0x48, 0x0c, 0x55, 0x19, 0x8b, 0xd2, /* ..H.U... */
+	0x47, 0x96, 0x80, 0x18, 0x00, 0x73, 0xfc, 0x5c, /* G....s.\ */
+	0x00, 0x00, 0x01, 0x01, 0x08, 0x0a, 0x80, 0x02, /* ........ */
+	0x3c, 0xbe, 0x00, 0x0a, 0xf2, 0x19, 0xff, 0xff, /* <....... */
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, /* ........ */
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00, 0x37, /* .......7 */
+	0x01, 0x04, 0xfd, 0xea, 0x00, 0x5a, 0x0a, 0x28, /* .....Z.( */
+	0x00, 0x0a, 0x1a, 0x02, 0x06, 0x01, 0x04, 0x00, /* ........ */
+	0x01, 0x00, 0x01, 0x02, 0x02, 0x80, 0x00, 0x02, /* ........ */
+	0x02, 0x02, 0x00, 0x02, 0x08, 0x40, 0x06, 0x00, /* .....@.. */
+	0x78, 0x00, 0x01, 0x01, 0x00, 0xf5, 0xde, 0xb0, /* x....... */
+	0xf5, 0x00, 0x14, 0x00, 0x01, 0x00, 0x01, 0x00, /* ........ */
+	0x01, 0x00, 0x0c, 0x00, 0x02, 0x01, 0x00, 0x00, /* ........ */
+	0x00, /* . */
+}
+
+func TestFixture(t *testing.T) {
+	ether := &fixture.EthernetII{}
+
+	err := packet.Unmarshal(frame, ether)
+	assert.NoError(t, err, "failed to decode etherframe")
+	fmt.Printf("ether %+v\n", ether)
+
+	vlan, ok := ether.Body.(*fixture.VLAN)
+	assert.True(t, ok, "failed to find vlan")
+	fmt.Printf("vlan %+v\n", vlan)
+
+	ipv4, ok := vlan.Body.(*fixture.IPv4)
+	assert.True(t, ok, "failed to find ipv4")
+	fmt.Printf("ipv4 %+v\n", ipv4)
+
+	tcp, ok := ipv4.Body.(*fixture.TCP)
+	assert.True(t, ok, "failed to find TCP")
+	fmt.Printf("tcp %+v\n", tcp)
+
+	bgp, ok := tcp.Body.(*bgp.Message)
+	fmt.Printf("bgp %+v\n", bgp)
+
+	assert.True(t, ok, "failed to find BGP")
+	assert.NotNil(t, bgp, "bgp is null")
+}
+func TestCompare(t *testing.T) {
+	// Decode a packet
+	gp := gopacket.NewPacket(frame, layers.LayerTypeEthernet, gopacket.Default)
+
+	ip := &fixture.IPv4{}
+	err := packet.Unmarshal(frame[18:], ip)
+	assert.NoError(t, err, "failed to unmarshal packet")
+
+	// expectedIP := fixture.IPv4{Version: 4, IHL: 5, Protocol: 6}
+	// if !assert.ObjectsAreEqual(expectedIP, ip) {
+	// 	fmt.Printf("object: *(%v)* *(%v)*", expectedIP, ip)
+	// }
+	gpIPLayer := gp.Layer(layers.LayerTypeIPv4)
+	assert.NotNil(t, gpIPLayer, "tcp layer decoded")
+	gpIP, _ := gpIPLayer.(*layers.IPv4)
+	assert.True(t, IPPacketEqual(t, ip, gpIP))
+
+	assert.NotNil(t, ip.Body, "body should be populated with TCP")
+	tcp, ok := ip.Body.(*fixture.TCP)
+	assert.True(t, ok, "tcp message from ip")
+	assert.NotNil(t, tcp, "tcp body not found")
+	fmt.Printf("TCP: %+v\n", tcp)
+
+	gpTCPLayer := gp.Layer(layers.LayerTypeTCP)
+	assert.NotNil(t, gpTCPLayer, "tcp layer decoded")
+	gpTCP, _ := gpTCPLayer.(*layers.TCP)
+	fmt.Printf("GP TCP: %+v\n", gpTCP)
+
+	assert.Equal(t, []fixture.TCPOption{
+		fixture.TCPOption{Kind: fixture.NOP},
+		fixture.TCPOption{Kind: fixture.NOP},
+		fixture.TCPOption{Kind: fixture.Timestamps, Length: 10, Value: &fixture.TimestampsOption{Value: 0x80023cbe, EchoReply: 0x000af219}},
+	}, tcp.Options, "options")
+	assert.Equal(t, len(gpTCP.Options), len(tcp.Options), "same number of options")
+	for i, o := range gpTCP.Options {
+		assert.Equal(t, uint8(o.OptionType), uint8(tcp.Options[i].Kind), "same option kind")
+	}
+}
+
+func IPPacketEqual(t *testing.T, ip *fixture.IPv4, gp *layers.IPv4) bool {
+	assert.Equal(t, gp.Version, ip.Version)
+	assert.Equal(t, gp.IHL, ip.IHL)
+	assert.Equal(t, gp.Length, ip.Length)
+	assert.Equal(t, gp.Checksum, uint16(ip.Checksum))
+	fmt.Printf("object: *(%v)* *(%v)*\n", ip, gp)
+	return true
+}
+
+/*
+Running tool: /usr/local/go/bin/go test -benchmem -run=^$ github.com/nickchen/packet -bench ^(BenchmarkGoPacket)$
+
+for Etherframe -> VLAN -> IP -> TCP
+
+goos: darwin
+goarch: amd64
+pkg: github.com/nickchen/packet
+BenchmarkGoPacket-8   	 1000000	      1035 ns/op	    1240 B/op	      12 allocs/op
+BenchmarkGoPacket-12    	 2000000	       877 ns/op	    1240 B/op	      12 allocs/op
+PASS
+ok  	github.com/nickchen/packet	1.396s
+Success: Benchmarks passed.
+*/
+func BenchmarkGoPacket(b *testing.B) {
+	// run the Fib function b.N times
+	for n := 0; n < b.N; n++ {
+		_ = gopacket.NewPacket(frame, layers.LayerTypeEthernet, gopacket.Default)
+	}
+}
+
+/* for Etherframe -> VLAN -> IP -> TCP -> BGP -> Open ... 23 allocs */
+func BenchmarkPacket(b *testing.B) {
+	b.ReportAllocs()
+	ether := &fixture.EthernetII{}
+	for n := 0; n < b.N; n++ {
+		_ = packet.Unmarshal(frame, ether)
+	}
+}
+
+func TestReadPCAP(t *testing.T) {
+	pcapFile := "fixture/NTLM-wenchao.pcap"
+	if _, err := os.Stat(pcapFile); !os.IsNotExist(err) {
+
+		pcap, err := fixture.OpenPCAP(pcapFile)
+		assert.NoError(t, err, "failed to open pcap")
+		if err == nil {
+			defer pcap.Close()
+			count := 0
+			for pcap.Next() {
+				fmt.Printf("=====\n")
+				v, err := pcap.Packet().Decode()
+				assert.NoError(t, err, "failed to decode")
+				ether, _ := v.(*fixture.EthernetII)
+				assert.NotNil(t, ether, "ethernet capture")
+				fmt.Printf("Packet: %+v\n", ether)
+				ip, _ := ether.Body.(*fixture.IPv4)
+				assert.NotNil(t, ip, "ether->ip")
+				fmt.Printf("IP: %+v\n", ip)
+
+				tcp, _ := ip.Body.(*fixture.TCP)
+				assert.NotNil(t, tcp, "ip->tcp")
+				raw, _ := tcp.Body.(*packet.Raw)
+				assert.NotNil(t, raw, "tcp->raw")
+				fmt.Printf("TCP: %s\n", string(*raw))
+				count++
+				if count >= 5 {
+					break
+				}
+			}
+			assert.NoError(t, pcap.Err(), "failed to read")
+		}
+	}
+}
+
+func TestUnmarshalRaw(t *testing.T) {
+	// change the ether type of the VLAN to an unknown protocol
+	unknown := append([]byte{}, frame...)
+	unknown[16], unknown[17] = 0x88, 0xb5
+
+	ether := &fixture.EthernetII{}
+	assert.NoError(t, packet.Unmarshal(unknown, ether), "failed to decode etherframe")
+	vlan, ok := ether.Body.(*fixture.VLAN)
+	assert.True(t, ok, "failed to find vlan")
+	raw, ok := vlan.Body.(*packet.Raw)
+	assert.True(t, ok, "failed to find raw body")
+	assert.Equal(t, packet.Raw(unknown[18:]), *raw)
+
+	b, err := packet.Marshal(ether)
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, unknown, b, "raw body re-emitted")
+}
+
+var synthesizedFrame = []byte{
+	0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77, 0xfa, 0x16, /* ..>..w.. */
+	0x3e, 0x1a, 0x43, 0xcb, 0x81, 0x00, 0x0f, 0xfe, /* >.C..... */
+	0x08, 0x00, 0x45, 0x00, 0x00, 0x3b, 0x9a, 0xaf, /* ..E..;.. */
+	0x40, 0x00, 0x01, 0x06, 0x00, 0x00, 0x0a, 0x14, /* @....... */
+	0x00, 0x0a, 0x0a, 0x0a, 0x00, 0x14, 0x89, 0xce, /* ........ */
+	0x00, 0xb3, 0x48, 0x0c, 0x55, 0x19, 0x8b, 0xd2, /* ..H.U... */
+	0x47, 0x96, 0x50, 0x18, 0x00, 0x73, 0x00, 0x00, /* G.P..s.. */
+	0x00, 0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, /* ........ */
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, /* ........ */
+	0xff, 0xff, 0x00, 0x13, 0x04, /* ..... */
+}
+
+func synthesizedEthernet() *fixture.EthernetII {
+	return &fixture.EthernetII{
+		Source: fixture.Mac{0xfa, 0x16, 0x3e, 0x85, 0x92, 0x77},
+		Dest:   fixture.Mac{0xfa, 0x16, 0x3e, 0x1a, 0x43, 0xcb},
+		Body: &fixture.VLAN{
+			ID: 4094,
+			Body: &fixture.IPv4{
+				Version: 4,
+				IHL:     5,
+				Length:  59,
+				ID:      0x9aaf,
+				Flags:   fixture.DFrag,
+				TTL:     1,
+				Source:  net.IP{10, 20, 0, 10},
+				Dest:    net.IP{10, 10, 0, 20},
+				Body: &fixture.TCP{
+					Source:     35278,
+					Dest:       179,
+					Sequence:   0x480c5519,
+					Ack:        0x8bd24796,
+					DataOffset: 5,
+					Flags:      fixture.PSH | fixture.ACK,
+					WindowSize: 115,
+					Body: &bgp.Message{
+						Marker: [16]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+							0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+						Length: 19,
+						Body:   &bgp.Keepalive{},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMarshalInstanceType(t *testing.T) {
+	b, err := packet.Marshal(synthesizedEthernet())
+	assert.NoError(t, err, "failed to encode")
+	assert.Equal(t, synthesizedFrame, b, "discriminators filled from body")
+
+	ether := &fixture.EthernetII{}
+	assert.NoError(t, packet.Unmarshal(b, ether), "failed to decode")
+	assert.Equal(t, fixture.EtherType(0x8100), ether.Type)
+	vlan, ok := ether.Body.(*fixture.VLAN)
+	assert.True(t, ok, "failed to find vlan")
+	assert.Equal(t, fixture.EtherType(0x0800), vlan.Type)
+	ipv4, ok := vlan.Body.(*fixture.IPv4)
+	assert.True(t, ok, "failed to find ipv4")
+	assert.Equal(t, fixture.IPProtocol(6), ipv4.Protocol)
+	tcp, ok := ipv4.Body.(*fixture.TCP)
+	assert.True(t, ok, "failed to find tcp")
+	_, ok = tcp.Body.(*bgp.Message)
+	assert.True(t, ok, "failed to find bgp")
+}
+
+func TestMarshalInstanceTypeMismatch(t *testing.T) {
+	ether := synthesizedEthernet()
+	ether.Type = fixture.EtherType(0x0800)
+	_, err := packet.Marshal(ether)
+	assert.Error(t, err, "IPv4 type with VLAN body")
+	_, ok := err.(*packet.MarshalInstanceTypeError)
+	assert.True(t, ok, "expecting MarshalInstanceTypeError, got %T", err)
+
+	ether = synthesizedEthernet()
+	ether.Type = fixture.EtherType(0x88b5)
+	_, err = packet.Marshal(ether)
+	assert.IsType(t, &packet.MarshalInstanceTypeError{}, err, "type without an instance with VLAN body")
+
+	ether = synthesizedEthernet()
+	ether.Type = fixture.EtherType(0x8100)
+	_, err = packet.Marshal(ether)
+	assert.NoError(t, err, "matching type is kept")
+
+	ether.Type, ether.Body = fixture.EtherType(0x88b5), &packet.Raw{0x01, 0x02}
+	_, err = packet.Marshal(ether)
+	assert.NoError(t, err, "type without an instance with Raw body")
+}
@@ -0,0 +1,16 @@
+package packet
+
+// Raw holds the remaining bytes for an interface{} field when InstanceFor doesn't provide an instance, so that unknown
+// protocols are preserved, and re-emitted verbatim by Marshal.
+type Raw []byte
+
+// MarshalPACKET returns the bytes as is
+func (r Raw) MarshalPACKET() ([]byte, error) {
+	return r, nil
+}
+
+// UnmarshalPACKET keeps a copy of the bytes
+func (r *Raw) UnmarshalPACKET(b []byte) error {
+	*r = append((*r)[:0], b...)
+	return nil
+}
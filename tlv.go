@@ -0,0 +1,137 @@
+package packet
+
+import (
+	"reflect"
+)
+
+// TLV interface describes a type-length-value struct, whose fields are tagged with `tlv=type`, `tlv=length` and
+// `tlv=value`, the type being optional, e.g. IPv4 header with IHL. The fields before the value are counted as header. The value is bounded by the length,
+// and its instance is created from the Registry, falling back to InstanceFor and then Raw. Marshal patches the
+// length from the encoded value, so the length of a decoded struct follows the changes to its value.
+type TLV interface {
+	TLV() *TLVSpec
+}
+
+// TLVSpec specifies the encoding of a TLV, it's expected to be shared by all the instances of the struct
+type TLVSpec struct {
+	// Padding types are a single byte of type without length and value, e.g. TCP NOP and EOL
+	Padding []uint64
+	// End types end a list of TLVs, the data after the end TLV being skipped when decoding, e.g. LLDP End followed
+	// by the Ethernet padding
+	End []uint64
+	// Inclusive when the length counts the header along with the value
+	Inclusive bool
+	// Unit of the length in bytes, 1 when it's zero
+	Unit uint64
+	// Align of the value in bytes, the value is padded with zeros that are not counted by the length, e.g. pcapng options
+	Align uint64
+	// Registry maps the type to a pointer of the value struct, a new instance is created for each value
+	Registry map[uint64]interface{}
+}
+
+// tlv role of a field in the TLV struct
+type tlv uint8
+
+const (
+	_tlvNone tlv = iota
+	_tlvType
+	_tlvLength
+	_tlvValue
+)
+
+func (s *TLVSpec) padding(t uint64) bool {
+	for _, p := range s.Padding {
+		if p == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TLVSpec) end(t uint64) bool {
+	for _, e := range s.End {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *TLVSpec) unit() uint64 {
+	if s.Unit == 0 {
+		return 1
+	}
+	return s.Unit
+}
+
+// valueLength returns the length in bytes of the value, from the length field and the size of the header
+func (s *TLVSpec) valueLength(length, header uint64) (uint64, bool) {
+	size := length * s.unit()
+	if s.Inclusive {
+		if size < header {
+			return 0, false
+		}
+		size -= header
+	}
+	return size, true
+}
+
+// length returns the length field for the size in bytes of the value and the header, rounded up to the unit
+func (s *TLVSpec) length(value, header uint64) uint64 {
+	size := value
+	if s.Inclusive {
+		size += header
+	}
+	return (size + s.unit() - 1) / s.unit()
+}
+
+// alignPadding returns the number of bytes padding the value of the size to the alignment
+func (s *TLVSpec) alignPadding(size uint64) uint64 {
+	if s.Align <= 1 {
+		return 0
+	}
+	return (s.Align - size%s.Align) % s.Align
+}
+
+// instance returns a new instance of the value for the type, or nil when it's not registered
+func (s *TLVSpec) instance(t uint64) interface{} {
+	p, ok := s.Registry[t]
+	if !ok {
+		return nil
+	}
+	return reflect.New(reflect.TypeOf(p).Elem()).Interface()
+}
+
+// typeOf returns the type registered for the instance, the type is ambiguous when more than one is registered
+func (s *TLVSpec) typeOf(instance interface{}) (uint64, bool) {
+	it := reflect.TypeOf(instance)
+	found := false
+	var t uint64
+	for k, p := range s.Registry {
+		if reflect.TypeOf(p) == it {
+			if found {
+				return 0, false
+			}
+			found, t = true, k
+		}
+	}
+	return t, found
+}
+
+// tlvSpec returns the spec when the struct is a TLV
+func tlvSpec(v reflect.Value) *TLVSpec {
+	if t, ok := v.Interface().(TLV); ok {
+		return t.TLV()
+	}
+	return nil
+}
+
+// tlvField returns the field with the role in the TLV struct
+func tlvField(v reflect.Value, vf *valueFields, role tlv) (reflect.Value, *field) {
+	for i, f := range *vf {
+		if f.tlv == role {
+			return v.Field(i), f
+		}
+	}
+	return reflect.Value{}, nil
+}
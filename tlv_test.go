@@ -0,0 +1,186 @@
+package packet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tlvOption struct {
+	Type   uint8       `packet:"tlv=type"`
+	Length uint8       `packet:"tlv=length"`
+	Value  interface{} `packet:"tlv=value"`
+}
+
+type tlvNumber struct {
+	Number uint16
+}
+
+var tlvOptionSpec = &TLVSpec{
+	Padding:   []uint64{0, 1},
+	Inclusive: true,
+	Registry:  map[uint64]interface{}{2: &tlvNumber{}},
+}
+
+func (o tlvOption) TLV() *TLVSpec {
+	return tlvOptionSpec
+}
+
+// tlvBits has 7 bits of type and 9 bits of length, as LLDP
+type tlvBits struct {
+	Type   uint8  `packet:"length=7b,tlv=type"`
+	Length uint16 `packet:"length=9b,tlv=length"`
+	Value  []byte `packet:"tlv=value"`
+}
+
+func (o tlvBits) TLV() *TLVSpec {
+	return &TLVSpec{}
+}
+
+// tlvWide has 2 bytes of length when the Flags is set, 4 bytes unit including the header
+type tlvWide struct {
+	Flags  uint8
+	Type   uint8       `packet:"tlv=type"`
+	Length uint16      `packet:"tlv=length,lengthfor"`
+	Value  interface{} `packet:"tlv=value"`
+}
+
+func (o tlvWide) TLV() *TLVSpec {
+	return &TLVSpec{Inclusive: true, Unit: 4}
+}
+
+func (o tlvWide) LengthFor(fieldname string) uint64 {
+	if o.Flags != 0 {
+		return 2
+	}
+	return 1
+}
+
+func TestTLV(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x04, 0x05, 0xb4, 0x00, 0x03, 0x03, 0xaa}
+
+	options := []tlvOption{}
+	assert.NoError(t, Unmarshal(data, &options))
+	assert.Equal(t, []tlvOption{
+		tlvOption{Type: 1},
+		tlvOption{Type: 2, Length: 4, Value: &tlvNumber{Number: 1460}},
+		tlvOption{Type: 0},
+		tlvOption{Type: 3, Length: 3, Value: &Raw{0xaa}},
+	}, options)
+
+	b, err := Marshal(&options)
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	// type and length inferred
+	b, err = Marshal(&[]tlvOption{tlvOption{Type: 1}, tlvOption{Value: &tlvNumber{Number: 1460}}})
+	assert.NoError(t, err)
+	assert.Equal(t, data[:5], b)
+
+	// stale length patched
+	b, err = Marshal(&tlvOption{Type: 3, Length: 5, Value: &Raw{0xaa}})
+	assert.NoError(t, err)
+	assert.Equal(t, data[6:], b)
+
+	// Type 2 is registered for tlvNumber
+	_, err = Marshal(&tlvOption{Type: 2, Value: &Raw{0xaa}})
+	assert.IsType(t, &MarshalInstanceTypeError{}, err)
+
+	err = Unmarshal([]byte{0x02, 0x05, 0x05, 0xb4}, &tlvOption{})
+	assert.IsType(t, &UnmarshalUnexpectedEnd{}, err)
+}
+
+func TestTLVBits(t *testing.T) {
+	data := []byte{0x02, 0x03, 0xaa, 0xbb, 0xcc, 0xfe, 0x00}
+
+	tlvs := []tlvBits{}
+	assert.NoError(t, Unmarshal(data, &tlvs))
+	assert.Equal(t, []tlvBits{
+		tlvBits{Type: 1, Length: 3, Value: []byte{0xaa, 0xbb, 0xcc}},
+		tlvBits{Type: 127},
+	}, tlvs)
+
+	b, err := Marshal(&[]tlvBits{tlvBits{Type: 1, Value: []byte{0xaa, 0xbb, 0xcc}}, tlvBits{Type: 127}})
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+
+	_, err = Marshal(&tlvBits{Type: 1, Value: make([]byte, 512)})
+	assert.IsType(t, &MarshalLengthError{}, err)
+}
+
+func TestTLVLengthWidth(t *testing.T) {
+	for _, data := range [][]byte{
+		{0x00, 0x01, 0x02, 0xaa, 0xbb, 0xcc, 0x00, 0x00},
+		{0x01, 0x01, 0x00, 0x02, 0xaa, 0xbb, 0xcc, 0x00},
+	} {
+		w := &tlvWide{}
+		assert.NoError(t, Unmarshal(data, w))
+		raw := Raw(data[len(data)-5:])
+		if w.Flags != 0 {
+			raw = Raw(data[len(data)-4:])
+		}
+		assert.Equal(t, &tlvWide{Flags: data[0], Type: 1, Length: 2, Value: &raw}, w)
+
+		// length and padding to the unit filled in
+		b, err := Marshal(&tlvWide{Flags: data[0], Type: 1, Value: &Raw{0xaa, 0xbb, 0xcc}})
+		assert.NoError(t, err)
+		assert.Equal(t, data, b)
+	}
+}
+
+// tlvAligned has the value aligned to 4 bytes, the padding not counted by the length, and ends with the type 0, as
+// pcapng options
+type tlvAligned struct {
+	Code   uint16 `packet:"tlv=type"`
+	Length uint16 `packet:"tlv=length"`
+	Value  []byte `packet:"tlv=value"`
+}
+
+func (o tlvAligned) TLV() *TLVSpec {
+	return &TLVSpec{End: []uint64{0}, Align: 4}
+}
+
+func TestTLVAlign(t *testing.T) {
+	data := []byte{0x02, 0x00, 0x03, 0x00, 0xaa, 0xbb, 0xcc, 0x00, 0x03, 0x00, 0x04, 0x00, 0x01, 0x02, 0x03, 0x04, 0x00, 0x00, 0x00, 0x00}
+	o := UnmarshalOptions{ByteOrder: binary.LittleEndian}
+
+	tlvs := []tlvAligned{}
+	assert.NoError(t, o.Unmarshal(data, &tlvs))
+	assert.Equal(t, []tlvAligned{
+		tlvAligned{Code: 2, Length: 3, Value: []byte{0xaa, 0xbb, 0xcc}},
+		tlvAligned{Code: 3, Length: 4, Value: []byte{0x01, 0x02, 0x03, 0x04}},
+		tlvAligned{Code: 0},
+	}, tlvs)
+
+	b, err := MarshalOptions{ByteOrder: binary.LittleEndian}.Marshal(&[]tlvAligned{
+		tlvAligned{Code: 2, Value: []byte{0xaa, 0xbb, 0xcc}},
+		tlvAligned{Code: 3, Length: 4, Value: []byte{0x01, 0x02, 0x03, 0x04}},
+		tlvAligned{Code: 0},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, data, b)
+}
+
+// tlvEnded ends with the type 0, as LLDP End
+type tlvEnded struct {
+	Type   uint8  `packet:"tlv=type"`
+	Length uint8  `packet:"tlv=length"`
+	Value  []byte `packet:"tlv=value"`
+}
+
+func (o tlvEnded) TLV() *TLVSpec {
+	return &TLVSpec{End: []uint64{0}}
+}
+
+func TestTLVEnd(t *testing.T) {
+	data := []byte{0x01, 0x01, 0xaa, 0x00, 0x00, 0xff, 0xff, 0xff}
+
+	tlvs := []tlvEnded{}
+	assert.NoError(t, Unmarshal(data, &tlvs))
+	assert.Equal(t, []tlvEnded{tlvEnded{Type: 1, Length: 1, Value: []byte{0xaa}}, tlvEnded{}}, tlvs, "data after the end skipped")
+
+	b, err := Marshal(&tlvs)
+	assert.NoError(t, err)
+	assert.Equal(t, data[:5], b)
+}
@@ -0,0 +1,58 @@
+package packet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type whenHeader struct {
+	Checksum bool
+	Key      bool
+	Flags    uint8   `packet:"length=6b"`
+	A        uint16  `packet:"when=Checksum-eq-1"`
+	B        uint32  `packet:"when=Key-ne-0"`
+	C        uint8   `packet:"when=Flags-and-0x10"`
+	D        uint8   `packet:"when=Flags-gt-32"`
+	E        uint8   `packet:"when=Checksum-eq-1|Flags-eq-1"`
+	Rest     []uint8 `packet:"lengthrest"`
+}
+
+func TestWhen(t *testing.T) {
+	for _, test := range []struct {
+		data []byte
+		h    *whenHeader
+	}{
+		{data: []byte{0x00, 0xff}, h: &whenHeader{Rest: []uint8{0xff}}},
+		{data: []byte{0x80, 0x12, 0x34, 0x05}, h: &whenHeader{Checksum: true, A: 0x1234, E: 5}},
+		{data: []byte{0x40, 0x00, 0x00, 0x00, 0x01}, h: &whenHeader{Key: true, B: 1}},
+		{data: []byte{0xd0, 0x12, 0x34, 0x00, 0x00, 0x00, 0x01, 0x02, 0x05}, h: &whenHeader{Checksum: true, Key: true, Flags: 0x10, A: 0x1234, B: 1, C: 2, E: 5}},
+		{data: []byte{0x31, 0x02, 0x03}, h: &whenHeader{Flags: 0x31, C: 2, D: 3}},
+		{data: []byte{0x01, 0x05}, h: &whenHeader{Flags: 0x01, E: 5}},
+	} {
+		h := &whenHeader{}
+		assert.NoError(t, Unmarshal(test.data, h))
+		assert.Equal(t, test.h, h)
+
+		b, err := Marshal(h)
+		assert.NoError(t, err)
+		assert.Equal(t, test.data, b)
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	type deep struct {
+		A uint8
+		B struct {
+			C uint8
+			D struct {
+				E uint8
+			}
+		}
+	}
+	d := &deep{}
+	err := UnmarshalOptions{MaxDepth: 2}.Unmarshal([]byte{0x01, 0x02, 0x03}, d)
+	assert.IsType(t, &UnmarshalDepthError{}, err)
+	assert.NoError(t, UnmarshalOptions{MaxDepth: 3}.Unmarshal([]byte{0x01, 0x02, 0x03}, d))
+	assert.Equal(t, uint8(3), d.B.D.E)
+}